@@ -0,0 +1,213 @@
+// Package analyzer provides an analysis.Analyzer applying the tag and rule
+// checks of commentlex to the comments of Go packages, so comment policies can
+// run with go vet style drivers and multicheckers.
+package analyzer
+
+import (
+	"flag"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	lexer "github.com/Acetolyne/commentlex"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer reports the comments having one of the tags given by its -comment-tags
+// flag, DefaultTags unless set, and the findings of the rules in the file given
+// by its -rules flag, see lexer.ParseRules. With -embed it also checks the
+// non-Go files of the package and the files embedded with //go:embed.
+var Analyzer = New(nil, nil, false)
+
+// DefaultTags lists the tags reported when none are given. Unlike
+// lexer.DefaultTags it leaves out NOTE, which marks no work to do.
+var DefaultTags = []string{"TODO", "FIXME", "HACK", "XXX", "BUG"}
+
+// New returns an analyzer reporting the comments having one of tags, or
+// DefaultTags if tags is nil, and the findings of rules. If embed is set the
+// non-Go files of a package and the files embedded with //go:embed are checked
+// as well. The returned analyzer has the flags -comment-tags, -rules and -embed
+// to change the configuration; -comment-tags= reports no tags.
+func New(rules []lexer.Rule, tags []string, embed bool) *analysis.Analyzer {
+	if tags == nil {
+		tags = DefaultTags
+	}
+	c := &checker{rules: rules, tags: tags, embed: embed}
+	a := &analysis.Analyzer{
+		Name: "commentlex",
+		Doc:  "report tagged comments and comments breaking commentlex rules",
+		Run:  c.run,
+	}
+	a.Flags.Init(a.Name, flag.ContinueOnError)
+	a.Flags.Var((*tagList)(&c.tags), "comment-tags", "comma separated tags of comments to report")
+	a.Flags.Var((*ruleFile)(&c.rules), "rules", "JSON file of rules, see lexer.ParseRules")
+	a.Flags.BoolVar(&c.embed, "embed", embed, "also check non-Go and embedded files")
+	return a
+}
+
+type checker struct {
+	rules []lexer.Rule
+	tags  []string
+	embed bool
+}
+
+type tagList []string
+
+func (t *tagList) String() string { return strings.Join(*t, ",") }
+
+func (t *tagList) Set(s string) error {
+	// not nil, which lexer.CommentInfo.Tags reads as its default tags
+	*t = []string{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
+}
+
+type ruleFile []lexer.Rule
+
+func (r *ruleFile) String() string { return "" }
+
+func (r *ruleFile) Set(file string) error {
+	rules, err := lexer.LoadRules(file)
+	if err != nil {
+		return err
+	}
+	*r = rules
+	return nil
+}
+
+func (c *checker) run(pass *analysis.Pass) (interface{}, error) {
+	for _, f := range pass.Files {
+		tf := pass.Fset.File(f.Pos())
+		var comments []lexer.CommentInfo
+		for _, group := range f.Comments {
+			for _, cm := range group.List {
+				pos, end := pass.Fset.Position(cm.Pos()), pass.Fset.Position(cm.End())
+				comments = append(comments, lexer.CommentInfo{
+					Text: cm.Text,
+					Pos:  lexer.Position{Filename: pos.Filename, Offset: pos.Offset, Line: pos.Line, Column: pos.Column},
+					End:  lexer.Position{Filename: end.Filename, Offset: end.Offset, Line: end.Line, Column: end.Column},
+				})
+			}
+		}
+		c.check(pass, tf, comments)
+	}
+	if !c.embed {
+		return nil, nil
+	}
+	seen := make(map[string]bool)
+	for _, file := range append(append([]string(nil), pass.OtherFiles...), embedded(pass)...) {
+		if seen[file] || !lexer.Supported(file) {
+			continue
+		}
+		seen[file] = true
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		// non-Go files are not in the file set, see analysis.Pass.OtherFiles
+		tf := pass.Fset.AddFile(file, -1, len(src))
+		tf.SetLinesForContent(src)
+		c.check(pass, tf, lexer.ReadComments(file, strings.NewReader(string(src)), ""))
+	}
+	return nil, nil
+}
+
+// check reports the tagged comments and rule findings of the comments of the
+// file tf. Comments suppressed by commentlex: directives are skipped.
+func (c *checker) check(pass *analysis.Pass, tf *token.File, comments []lexer.CommentInfo) {
+	comments, _ = lexer.Suppress(comments, comments)
+	for _, cm := range comments {
+		if tags := cm.Tags(c.tags); len(tags) > 0 {
+			pass.Report(analysis.Diagnostic{
+				Pos:      tf.Pos(cm.Pos.Offset),
+				End:      tf.Pos(cm.End.Offset),
+				Category: tags[0],
+				Message:  fmt.Sprintf("%s comment: %s", tags[0], firstLine(cm.Text)),
+			})
+		}
+	}
+	for _, f := range lexer.CheckRules(c.rules, comments) {
+		d := analysis.Diagnostic{
+			Pos:      tf.Pos(f.Pos.Offset),
+			End:      tf.Pos(f.End.Offset),
+			Category: f.Rule,
+			Message:  f.Message,
+		}
+		if f.Fix != nil {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message: fmt.Sprintf("Replace with %q", f.Fix.NewText),
+				TextEdits: []analysis.TextEdit{{
+					Pos:     tf.Pos(f.Fix.Offset),
+					End:     tf.Pos(f.Fix.End),
+					NewText: []byte(f.Fix.NewText),
+				}},
+			}}
+		}
+		pass.Report(d)
+	}
+}
+
+// embedded returns the files matched by the //go:embed directives of the
+// package. Patterns matching directories are walked recursively.
+func embedded(pass *analysis.Pass) []string {
+	var files []string
+	for _, f := range pass.Files {
+		dir := filepath.Dir(pass.Fset.Position(f.Pos()).Filename)
+		for _, group := range f.Comments {
+			for _, cm := range group.List {
+				if !strings.HasPrefix(cm.Text, "//go:embed ") {
+					continue
+				}
+				for _, pattern := range embedPatterns(cm.Text[len("//go:embed "):]) {
+					matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+					for _, m := range matches {
+						filepath.Walk(m, func(path string, info os.FileInfo, err error) error {
+							if err == nil && !info.IsDir() {
+								files = append(files, path)
+							}
+							return nil
+						})
+					}
+				}
+			}
+		}
+	}
+	return files
+}
+
+// embedPatterns splits the patterns of a //go:embed directive, which may be
+// quoted.
+func embedPatterns(s string) []string {
+	var patterns []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		end := strings.IndexAny(s, " \t")
+		quoted := s[0] == '"' || s[0] == '`'
+		if quoted {
+			end = strings.IndexByte(s[1:], s[0]) + 2
+		}
+		if end <= 1 {
+			end, quoted = len(s), false
+		}
+		p := s[:end]
+		s = s[end:]
+		if quoted {
+			p = p[1 : len(p)-1]
+		}
+		patterns = append(patterns, strings.TrimPrefix(p, "all:"))
+	}
+	return patterns
+}
+
+// firstLine returns the first line of text.
+func firstLine(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return strings.TrimSpace(text[:i])
+	}
+	return text
+}
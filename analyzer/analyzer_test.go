@@ -0,0 +1,79 @@
+package analyzer_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+	"github.com/Acetolyne/commentlex/analyzer"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	replace := "FIXME"
+	rule, err := lexer.NewRule("no-xxx", `XXX`, "use FIXME", &replace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := analyzer.New([]lexer.Rule{rule}, []string{"TODO", "HACK"}, false)
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), a, "a")
+}
+
+func TestAnalyzerDefaultTags(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.New(nil, nil, false), "c")
+
+	a := analyzer.New(nil, nil, false)
+	if err := a.Flags.Set("comment-tags", ""); err != nil {
+		t.Fatal(err)
+	}
+	// the want comments of c are left unmatched
+	var errs errorList
+	results := analysistest.Run(&errs, analysistest.TestData(), a, "c")
+	for _, e := range errs {
+		if !strings.Contains(e, ": no diagnostic was reported matching ") {
+			t.Error(e)
+		}
+	}
+	if len(errs) != 2 || len(results) != 1 || len(results[0].Diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics without tags, got %v and %d errors", results, len(errs))
+	}
+}
+
+// errorList records the errors of analysistest.
+type errorList []string
+
+func (e *errorList) Errorf(format string, args ...interface{}) {
+	*e = append(*e, fmt.Sprintf(format, args...))
+}
+
+func TestAnalyzerEmbeddedFiles(t *testing.T) {
+	a := analyzer.New(nil, nil, false)
+	if err := a.Flags.Set("comment-tags", "TODO"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Flags.Set("embed", "true"); err != nil {
+		t.Fatal(err)
+	}
+	// want comments cannot be written in embedded files, so the
+	// diagnostic is checked here and analysistest only reports it unexpected
+	var errs errorList
+	results := analysistest.Run(&errs, analysistest.TestData(), a, "b")
+	for _, e := range errs {
+		if !strings.HasSuffix(e, "b/assets/x.py:2:1: unexpected diagnostic: TODO comment: # TODO python") {
+			t.Error(e)
+		}
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected the diagnostic in x.py to be unexpected, got %d errors", len(errs))
+	}
+	if len(results) != 1 || len(results[0].Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic, got %v", results)
+	}
+	d := results[0].Diagnostics[0]
+	posn := results[0].Pass.Fset.Position(d.Pos)
+	if filepath.Base(posn.Filename) != "x.py" || posn.Line != 2 || posn.Column != 1 || !strings.HasPrefix(d.Message, "TODO comment: # TODO python") {
+		t.Fatalf("unexpected diagnostic %s: %s", posn, d.Message)
+	}
+}
@@ -0,0 +1,19 @@
+// Command commentlexvet runs the commentlex analyzer on Go packages.
+//
+// Usage:
+//
+//	commentlexvet [-comment-tags TODO,FIXME] [-rules rules.json] [-embed] [package ...]
+//
+// It can also run from go vet:
+//
+//	go vet -vettool=$(which commentlexvet) ./...
+package main
+
+import (
+	"github.com/Acetolyne/commentlex/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
@@ -0,0 +1,10 @@
+package a
+
+var x = 1 // XXX remove // want "use FIXME"
+
+// TODO(jane) tidy up // want `T.DO comment: // TODO\(jane\) tidy up`
+
+// commentlex:ignore-next-line
+// XXX ignored
+
+/* a HACK */ // want `H.CK comment: /\* a H.CK \*/`
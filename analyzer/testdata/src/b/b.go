@@ -0,0 +1,6 @@
+package b
+
+import "embed"
+
+//go:embed assets
+var assets embed.FS
@@ -0,0 +1,7 @@
+package c
+
+// NOTE: not reported by default
+
+// TODO: reported // want `TODO comment: // TODO: reported`
+
+// BUG(jane): reported // want `BUG comment: // BUG\(jane\): reported`
@@ -0,0 +1,135 @@
+package lexer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Fingerprint identifies a comment independent of where it is in its file,
+// so comments keep their fingerprint when lines are added above them. It is
+// derived from the file name and the comment text with white space collapsed.
+func Fingerprint(c CommentInfo) string {
+	h := sha256.New()
+	h.Write([]byte(filepath.ToSlash(filepath.Clean(c.Pos.Filename))))
+	h.Write([]byte{0})
+	h.Write([]byte(normalize(c.Text)))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// A Baseline records the comments that already exist in a code base so only
+// comments introduced later are reported.
+type Baseline struct {
+	Comments []BaselineEntry
+}
+
+// BaselineEntry is a comment recorded in a Baseline. Count is the number of
+// comments in the file with the same fingerprint.
+type BaselineEntry struct {
+	Fingerprint string
+	File        string
+	Text        string
+	Count       int
+}
+
+// NewBaseline returns a Baseline recording comments.
+func NewBaseline(comments []CommentInfo) *Baseline {
+	b := &Baseline{}
+	index := make(map[string]int)
+	for _, c := range comments {
+		fp := Fingerprint(c)
+		if i, ok := index[fp]; ok {
+			b.Comments[i].Count++
+			continue
+		}
+		index[fp] = len(b.Comments)
+		b.Comments = append(b.Comments, BaselineEntry{
+			Fingerprint: fp,
+			File:        filepath.ToSlash(c.Pos.Filename),
+			Text:        c.Text,
+			Count:       1,
+		})
+	}
+	return b
+}
+
+// LoadBaseline reads a baseline file written by Save.
+func LoadBaseline(file string) (*Baseline, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	b := &Baseline{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Save writes the baseline to file as JSON, sorted by file so changes to the
+// baseline are easy to review.
+func (b *Baseline) Save(file string) error {
+	sort.SliceStable(b.Comments, func(i, j int) bool {
+		if b.Comments[i].File != b.Comments[j].File {
+			return b.Comments[i].File < b.Comments[j].File
+		}
+		return b.Comments[i].Text < b.Comments[j].Text
+	})
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// counts returns the recorded count of each fingerprint.
+func (b *Baseline) counts() map[string]int {
+	counts := make(map[string]int, len(b.Comments))
+	for _, e := range b.Comments {
+		counts[e.Fingerprint] += e.Count
+	}
+	return counts
+}
+
+// Filter returns the comments that are not in the baseline. When a comment
+// occurs more often than recorded, the extra occurrences are returned.
+func (b *Baseline) Filter(comments []CommentInfo) []CommentInfo {
+	counts := b.counts()
+	var added []CommentInfo
+	for _, c := range comments {
+		fp := Fingerprint(c)
+		if counts[fp] > 0 {
+			counts[fp]--
+			continue
+		}
+		added = append(added, c)
+	}
+	return added
+}
+
+// Ratchet lowers the recorded counts to the number of matching comments
+// still present, dropping entries that are gone completely, so fixed
+// comments cannot come back unnoticed. Counts are never raised. It reports
+// whether the baseline changed and needs to be saved.
+func (b *Baseline) Ratchet(comments []CommentInfo) bool {
+	found := make(map[string]int)
+	for _, c := range comments {
+		found[Fingerprint(c)]++
+	}
+	changed := false
+	kept := b.Comments[:0]
+	for _, e := range b.Comments {
+		if n := found[e.Fingerprint]; n < e.Count {
+			e.Count = n
+			changed = true
+		}
+		if e.Count > 0 {
+			kept = append(kept, e)
+		}
+	}
+	b.Comments = kept
+	return changed
+}
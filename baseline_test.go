@@ -0,0 +1,50 @@
+package lexer_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestBaselineOnlyReportsNewComments(t *testing.T) {
+	old := lexer.ReadComments("a.go", strings.NewReader("//@todo one\n//@todo two\n"), "@todo")
+	file := filepath.Join(t.TempDir(), "baseline.json")
+	if err := lexer.NewBaseline(old).Save(file); err != nil {
+		t.Fatal(err)
+	}
+	b, err := lexer.LoadBaseline(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// moved comments are still known, duplicates and new comments are not
+	cur := lexer.ReadComments("a.go", strings.NewReader("package a\n\n//@todo two\n//@todo one\n//@todo one\n//@todo three\n"), "@todo")
+	res := ""
+	for _, c := range b.Filter(cur) {
+		res += fmt.Sprintf("%d %s|", c.Pos.Line, c.Text)
+	}
+
+	want := "5 //@todo one|6 //@todo three|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("baseline did not filter known comments")
+	}
+}
+
+func TestBaselineRatchet(t *testing.T) {
+	b := lexer.NewBaseline(lexer.ReadComments("a.go", strings.NewReader("//@todo one\n//@todo one\n//@todo two\n"), "@todo"))
+	cur := lexer.ReadComments("a.go", strings.NewReader("//@todo one\n//@todo new\n"), "@todo")
+
+	if !b.Ratchet(cur) {
+		t.Fatalf("ratchet should report a change")
+	}
+	if len(b.Comments) != 1 || b.Comments[0].Text != "//@todo one" || b.Comments[0].Count != 1 {
+		t.Fatalf("unexpected baseline after ratchet %+v", b.Comments)
+	}
+	if b.Ratchet(cur) {
+		t.Fatalf("ratchet without removed comments should not change the baseline")
+	}
+}
@@ -0,0 +1,168 @@
+package lexer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlameInfo describes the commit that last changed a comment.
+type BlameInfo struct {
+	Author string
+	Email  string
+	Commit string
+	Time   time.Time
+}
+
+// A Blamer attaches git blame information to comments using the local git
+// command. Results are cached per file so lines are only blamed once, and
+// files outside a git repository or not yet committed are simply skipped.
+// The zero value is ready to use.
+type Blamer struct {
+	lines map[string]map[int]*BlameInfo // blamed lines per file
+	skip  map[string]bool               // files git could not blame
+}
+
+// Annotate sets the Blame field of each comment to the most recent commit
+// that changed any of the lines the comment is on. Comments that cannot be
+// blamed keep a nil Blame field.
+func (b *Blamer) Annotate(comments []CommentInfo) {
+	if b.lines == nil {
+		b.lines = make(map[string]map[int]*BlameInfo)
+		b.skip = make(map[string]bool)
+	}
+
+	// collect the line ranges that are not cached yet, per file
+	var files []string
+	ranges := make(map[string][]string)
+	for _, c := range comments {
+		file := c.Pos.Filename
+		if b.skip[file] || b.cached(file, c.Pos.Line, c.End.Line) {
+			continue
+		}
+		if _, ok := ranges[file]; !ok {
+			files = append(files, file)
+		}
+		ranges[file] = append(ranges[file], fmt.Sprintf("-L%d,%d", c.Pos.Line, c.End.Line))
+	}
+	for _, file := range files {
+		if err := b.blame(file, ranges[file]); err != nil {
+			b.skip[file] = true
+		}
+	}
+
+	for i, c := range comments {
+		lines := b.lines[c.Pos.Filename]
+		for l := c.Pos.Line; l <= c.End.Line; l++ {
+			if info := lines[l]; info != nil && (comments[i].Blame == nil || info.Time.After(comments[i].Blame.Time)) {
+				comments[i].Blame = info
+			}
+		}
+	}
+}
+
+// cached reports whether lines first to last of file have been blamed.
+func (b *Blamer) cached(file string, first, last int) bool {
+	lines, ok := b.lines[file]
+	if !ok {
+		return false
+	}
+	for l := first; l <= last; l++ {
+		if _, ok := lines[l]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// blame runs git blame on the given line ranges of file and caches the result.
+func (b *Blamer) blame(file string, ranges []string) error {
+	args := append([]string{"blame", "--porcelain"}, ranges...)
+	args = append(args, "--", filepath.Base(file))
+	out, err := git(filepath.Dir(file), args...)
+	if err != nil {
+		return err
+	}
+	lines := b.lines[file]
+	if lines == nil {
+		lines = make(map[int]*BlameInfo)
+		b.lines[file] = lines
+	}
+	for line, info := range parseBlame(out) {
+		lines[line] = info
+	}
+	return nil
+}
+
+// parseBlame parses the output of git blame --porcelain into the commit of
+// each line. Lines that are not committed yet map to nil.
+func parseBlame(out []byte) map[int]*BlameInfo {
+	lines := make(map[int]*BlameInfo)
+	commits := make(map[string]*BlameInfo)
+	var cur *BlameInfo
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		text := sc.Text()
+		if strings.HasPrefix(text, "\t") {
+			// line content ends the entry
+			continue
+		}
+		key, value := text, ""
+		if i := strings.IndexByte(text, ' '); i >= 0 {
+			key, value = text[:i], text[i+1:]
+		}
+		switch key {
+		case "author":
+			cur.Author = value
+		case "author-mail":
+			cur.Email = strings.Trim(value, "<>")
+		case "author-time":
+			sec, _ := strconv.ParseInt(value, 10, 64)
+			cur.Time = time.Unix(sec, 0)
+		default:
+			if !isObjectName(key) {
+				continue
+			}
+			// header: <commit> <original line> <final line> [<lines in group>]
+			fields := strings.Fields(value)
+			if len(fields) < 2 {
+				continue
+			}
+			line, err := strconv.Atoi(fields[1])
+			if err != nil {
+				continue
+			}
+			cur = commits[key]
+			if cur == nil {
+				cur = &BlameInfo{Commit: key}
+				commits[key] = cur
+			}
+			lines[line] = cur
+		}
+	}
+	for line, info := range lines {
+		if strings.Trim(info.Commit, "0") == "" {
+			lines[line] = nil
+		}
+	}
+	return lines
+}
+
+// isObjectName reports whether s is the hex name of a git object, 40
+// characters long in SHA-1 repositories and 64 in SHA-256 ones.
+func isObjectName(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
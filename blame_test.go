@@ -0,0 +1,112 @@
+package lexer_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+// gitRepo creates a git repository in a temporary directory holding files
+// and commits them as author Jane Doe. It returns the repository directory.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q")
+	gitCommit(t, dir, files)
+	return dir
+}
+
+// gitCommit writes files into the repository at dir and commits them.
+func gitCommit(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", "test")
+}
+
+// gitRun runs git in dir with a fixed author and committer.
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Jane Doe", "GIT_AUTHOR_EMAIL=jane@example.com",
+		"GIT_COMMITTER_NAME=Jane Doe", "GIT_COMMITTER_EMAIL=jane@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestBlameAddsAuthor(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"main.go": "package main\n\n//@todo blame me\n"})
+	comments, err := lexer.ScanFile(filepath.Join(dir, "main.go"), "@todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b lexer.Blamer
+	b.Annotate(comments)
+
+	if len(comments) != 1 || comments[0].Blame == nil {
+		t.Fatalf("comment was not blamed: %+v", comments)
+	}
+	if got := comments[0].Blame; got.Author != "Jane Doe" || got.Email != "jane@example.com" || len(got.Commit) != 40 {
+		t.Fatalf("unexpected blame %+v", got)
+	}
+}
+
+func TestBlameSHA256Repository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q", "--object-format=sha256")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		t.Skip("git does not support SHA-256 repositories")
+	}
+	gitCommit(t, dir, map[string]string{"main.go": "//@todo blame me\n"})
+	comments, err := lexer.ScanFile(filepath.Join(dir, "main.go"), "@todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b lexer.Blamer
+	b.Annotate(comments)
+
+	if len(comments) != 1 || comments[0].Blame == nil || len(comments[0].Blame.Commit) != 64 || comments[0].Blame.Author != "Jane Doe" {
+		t.Fatalf("comment in a SHA-256 repository not blamed: %+v", comments)
+	}
+}
+
+func TestBlameOutsideRepository(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(file, []byte("//@todo not in git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	comments, err := lexer.ScanFile(file, "@todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b lexer.Blamer
+	b.Annotate(comments)
+
+	if len(comments) != 1 || comments[0].Blame != nil {
+		t.Fatalf("comment outside a repository should not be blamed: %+v", comments)
+	}
+}
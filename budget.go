@@ -0,0 +1,110 @@
+package lexer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Budget limits how many comments with a tag the files matching a path
+// pattern may have. Patterns use the CODEOWNERS syntax and are matched
+// against the file names of the comments, which are relative to the
+// directory the scan was started in. The tag * counts every comment.
+type Budget struct {
+	Pattern string
+	Tag     string
+	Max     int
+
+	re *regexp.Regexp
+}
+
+// BudgetResult is the outcome of checking a Budget.
+type BudgetResult struct {
+	Budget   Budget
+	Count    int
+	Comments []CommentInfo // the comments counted against the budget
+}
+
+// Exceeded reports whether more comments were found than the budget allows.
+func (r BudgetResult) Exceeded() bool { return r.Count > r.Budget.Max }
+
+// LoadBudgets reads the budgets in file, see ParseBudgets.
+func LoadBudgets(file string) ([]Budget, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseBudgets(f)
+}
+
+// ParseBudgets parses one budget per line in the form
+//
+//	<path pattern> <tag> <max>
+//
+// for example "pkg/payments/ FIXME 20" or "api/ HACK 0". Empty lines and
+// lines starting with # are ignored.
+func ParseBudgets(r io.Reader) ([]Budget, error) {
+	var budgets []Budget
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("budget line %d: want <path pattern> <tag> <max>", n)
+		}
+		max, err := strconv.Atoi(fields[2])
+		if err != nil || max < 0 {
+			return nil, fmt.Errorf("budget line %d: invalid maximum %q", n, fields[2])
+		}
+		b, err := NewBudget(fields[0], fields[1], max)
+		if err != nil {
+			return nil, fmt.Errorf("budget line %d: %v", n, err)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, sc.Err()
+}
+
+// NewBudget returns a Budget allowing max comments with tag in the files
+// matching pattern.
+func NewBudget(pattern string, tag string, max int) (Budget, error) {
+	re, err := ownerPattern(pattern)
+	if err != nil {
+		return Budget{}, err
+	}
+	return Budget{Pattern: pattern, Tag: tag, Max: max, re: re}, nil
+}
+
+// Counts reports whether comment c counts against the budget.
+func (b Budget) Counts(c CommentInfo) bool {
+	path := filepath.ToSlash(filepath.Clean(c.Pos.Filename))
+	if b.re == nil || !b.re.MatchString(path) {
+		return false
+	}
+	return b.Tag == "*" || c.HasTag(b.Tag)
+}
+
+// CheckBudgets counts the comments against each budget and returns one
+// result per budget, in the order of budgets.
+func CheckBudgets(budgets []Budget, comments []CommentInfo) []BudgetResult {
+	results := make([]BudgetResult, len(budgets))
+	for i, b := range budgets {
+		results[i].Budget = b
+		for _, c := range comments {
+			if b.Counts(c) {
+				results[i].Count++
+				results[i].Comments = append(results[i].Comments, c)
+			}
+		}
+	}
+	return results
+}
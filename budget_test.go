@@ -0,0 +1,39 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestCheckBudgets(t *testing.T) {
+	budgets, err := lexer.ParseBudgets(strings.NewReader("# payments may have a few\npkg/payments/ FIXME 1\napi/ HACK 0\n* * 10\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var comments []lexer.CommentInfo
+	comments = append(comments, lexer.ReadComments("pkg/payments/pay.go", strings.NewReader("// FIXME one\n// FIXME two\n"), "")...)
+	comments = append(comments, lexer.ReadComments("./api/api.go", strings.NewReader("// TODO fine\n"), "")...)
+
+	res := ""
+	for _, r := range lexer.CheckBudgets(budgets, comments) {
+		res += fmt.Sprintf("%s %s %d/%d %v|", r.Budget.Pattern, r.Budget.Tag, r.Count, r.Budget.Max, r.Exceeded())
+	}
+
+	want := "pkg/payments/ FIXME 2/1 true|api/ HACK 0/0 false|* * 3/10 false|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("budgets not counted as expected")
+	}
+}
+
+func TestParseBudgetsRejectsInvalidLines(t *testing.T) {
+	if _, err := lexer.ParseBudgets(strings.NewReader("api/ HACK\n")); err == nil {
+		t.Fatalf("missing maximum should be an error")
+	}
+	if _, err := lexer.ParseBudgets(strings.NewReader("api/ HACK -1\n")); err == nil {
+		t.Fatalf("negative maximum should be an error")
+	}
+}
@@ -0,0 +1,175 @@
+package lexer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// cacheVersion is part of every cache key. Change it when the scanner
+// returns different comments for the same input, as it did when literals
+// started to be skipped and the comment state was reset between sources.
+const cacheVersion = "2"
+
+// A Cache stores the comments found in file contents on disk, so unchanged
+// files do not need to be scanned again. Entries are keyed by a hash of the
+// contents, the comment characters and quotes used for the file's extension
+// and the match string, so changing any of them misses the cache.
+type Cache struct {
+	Dir     string // directory holding the cache entries
+	MaxSize int64  // size in bytes Trim shrinks the cache to, 0 for no limit
+}
+
+// DefaultCacheDir returns the commentlex directory in the user's cache
+// directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "commentlex"), nil
+}
+
+// cacheKey returns the key of the comments of src in a file with extension
+// ext scanned with match.
+func cacheKey(ext string, src []byte, match string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%q\x00%q\x00%q\x00", cacheVersion, syntaxFor(ext), Quotes[ext], match)
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path returns the file holding the entry with key.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// ScanFile returns the comments in file like the package level ScanFile,
+// using the cached result if the contents were scanned before.
+func (c *Cache) ScanFile(file string, match string) ([]CommentInfo, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key := cacheKey(filepath.Ext(file), src, match)
+	entry := c.path(key)
+	if data, err := os.ReadFile(entry); err == nil {
+		var comments []CommentInfo
+		if json.Unmarshal(data, &comments) == nil {
+			// the modification time tells Trim which entries were used last
+			now := time.Now()
+			os.Chtimes(entry, now, now)
+			for i := range comments {
+				comments[i].Pos.Filename, comments[i].End.Filename = file, file
+			}
+			return comments, nil
+		}
+	}
+
+	comments := ReadComments(file, bytes.NewReader(src), match)
+	stored := make([]CommentInfo, len(comments))
+	for i, cm := range comments {
+		cm.Pos.Filename, cm.End.Filename = "", ""
+		stored[i] = cm
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return nil, err
+	}
+	// write to a temporary file first so concurrent scans never read half an entry
+	tmp := fmt.Sprintf("%s.%d.tmp", entry, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, entry); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// ScanPaths returns the comments in paths like the package level ScanPaths,
+// using cached results for files scanned before. It trims the cache to
+// MaxSize when done.
+func (c *Cache) ScanPaths(paths []string, match string) ([]CommentInfo, error) {
+	var comments []CommentInfo
+	err := WalkPaths(paths, func(file string) error {
+		found, err := c.ScanFile(file, match)
+		comments = append(comments, found...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comments, c.Trim()
+}
+
+// Trim removes the least recently used entries until the cache holds at most
+// MaxSize bytes. It does nothing if MaxSize is 0.
+func (c *Cache) Trim() error {
+	if c.MaxSize <= 0 {
+		return nil
+	}
+	type entry struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var entries []entry
+	var total int64
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, entry{path, info.Size(), info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+	for _, e := range entries {
+		if total <= c.MaxSize {
+			break
+		}
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= e.size
+	}
+	return nil
+}
+
+// Clear removes every entry of the cache.
+func (c *Cache) Clear() error {
+	entries, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		// only remove what the cache created
+		if e.IsDir() && len(e.Name()) == 2 {
+			if err := os.RemoveAll(filepath.Join(c.Dir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package lexer_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestCacheReturnsSameComments(t *testing.T) {
+	cache := &lexer.Cache{Dir: t.TempDir()}
+	want, err := lexer.ScanFile("tests/test.lua", "@todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := cache.ScanFile("tests/test.lua", "@todo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			fmt.Println("got", got, "want", want)
+			t.Fatalf("cached scan %d differs from scan", i)
+		}
+	}
+}
+
+func TestCacheKeyedByContentAndMatch(t *testing.T) {
+	cache := &lexer.Cache{Dir: t.TempDir()}
+	file := filepath.Join(t.TempDir(), "a.go")
+	scan := func(content, match string) string {
+		if content != "" {
+			if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		comments, err := cache.ScanFile(file, match)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := ""
+		for _, c := range comments {
+			res += c.Text + "|"
+		}
+		return res
+	}
+
+	if got := scan("// one\n//@todo two\n", ""); got != "// one|//@todo two|" {
+		t.Fatalf("unexpected first scan %q", got)
+	}
+	if got := scan("", "@todo"); got != "//@todo two|" {
+		t.Fatalf("match not part of the cache key: %q", got)
+	}
+	if got := scan("// three\n", ""); got != "// three|" {
+		t.Fatalf("content not part of the cache key: %q", got)
+	}
+
+	quotes := lexer.Quotes[".go"]
+	defer func() { lexer.Quotes[".go"] = quotes }()
+	if got := scan("x := \"// four\"\n", ""); got != "" {
+		t.Fatalf("comment found in a literal: %q", got)
+	}
+	delete(lexer.Quotes, ".go")
+	if got := scan("", ""); got != "// four\"|" {
+		t.Fatalf("quotes not part of the cache key: %q", got)
+	}
+}
+
+func TestCacheTrimAndClear(t *testing.T) {
+	cache := &lexer.Cache{Dir: t.TempDir()}
+	if _, err := cache.ScanPaths([]string{"tests"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	size := func() (n int64) {
+		filepath.Walk(cache.Dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				n += info.Size()
+			}
+			return nil
+		})
+		return n
+	}
+	if size() == 0 {
+		t.Fatalf("nothing was cached")
+	}
+
+	cache.MaxSize = size() / 2
+	if err := cache.Trim(); err != nil {
+		t.Fatal(err)
+	}
+	if s := size(); s > cache.MaxSize || s == 0 {
+		t.Fatalf("cache of %d bytes not trimmed to %d", s, cache.MaxSize)
+	}
+	if err := cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if s := size(); s != 0 {
+		t.Fatalf("cache still holds %d bytes after clear", s)
+	}
+}
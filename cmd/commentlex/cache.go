@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+// addCacheFlags adds the flags configuring the cache to fs and returns a
+// function returning the configured cache once fs is parsed.
+func addCacheFlags(fs *flag.FlagSet) func() (*lexer.Cache, error) {
+	dir := fs.String("cache-dir", "", "directory of the cache, defaults to commentlex in the user cache directory")
+	size := fs.Int64("cache-size", 100, "maximum size of the cache in MiB, 0 for no limit")
+	return func() (*lexer.Cache, error) {
+		c := &lexer.Cache{Dir: *dir, MaxSize: *size << 20}
+		if c.Dir == "" {
+			var err error
+			if c.Dir, err = lexer.DefaultCacheDir(); err != nil {
+				return nil, err
+			}
+		}
+		return c, nil
+	}
+}
+
+func cacheCmd(args []string) error {
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	cacheFlags := addCacheFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: commentlex cache [flags] clear|trim")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	cache, err := cacheFlags()
+	if err != nil {
+		return err
+	}
+	switch fs.Arg(0) {
+	case "clear":
+		return cache.Clear()
+	case "trim":
+		return cache.Trim()
+	}
+	fs.Usage()
+	return errors.New("cache needs clear or trim")
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func convertCmd(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := fs.String("to", lexer.LineStyle, "comment style to convert to: line or block")
+	write := fs.Bool("w", false, "write the changes to the files instead of printing a diff")
+	rewrites := addExtensionlessFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: commentlex convert -to line|block [flags] [path ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	return lexer.WalkPaths(paths, func(file string) error {
+		if !rewrites(file) {
+			return nil
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		out, n, err := lexer.ConvertStyle(file, src, *to)
+		if err != nil || n == 0 {
+			return err
+		}
+		if !*write {
+			return lexer.WriteUnifiedDiff(os.Stdout, file, src, out)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, out, info.Mode())
+	})
+}
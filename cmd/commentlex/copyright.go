@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func copyrightCmd(args []string) error {
+	fs := flag.NewFlagSet("copyright", flag.ExitOnError)
+	year := fs.Int("year", 0, "year to extend copyright statements to, defaults to the year of the last commit of each file")
+	write := fs.Bool("w", false, "write the changes to the files instead of printing a diff")
+	rewrites := addExtensionlessFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: commentlex copyright [flags] [path ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	return lexer.WalkPaths(paths, func(file string) error {
+		if !rewrites(file) {
+			return nil
+		}
+		y := *year
+		if y == 0 {
+			var err error
+			if y, err = lexer.LastCommitYear(file); err != nil || y == 0 {
+				// files outside git or never committed are left alone
+				return nil
+			}
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		updated, changed, err := lexer.UpdateCopyrightYears(file, src, y)
+		if err != nil || !changed {
+			return err
+		}
+		if !*write {
+			return lexer.WriteUnifiedDiff(os.Stdout, file, src, updated)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, updated, info.Mode())
+	})
+}
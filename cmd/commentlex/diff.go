@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func diffCmd(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	repo := fs.String("repo", ".", "path of the git repository, may be a bare repository")
+	match := fs.String("match", "", "only compare comments matching this string, see Scanner.Match")
+	format := fs.String("format", "text", "output format: "+strings.Join(lexer.ReportFormats, ", "))
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: commentlex diff [flags] <old revision> <new revision>\n"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("diff needs two revisions")
+	}
+
+	var scans [2][]lexer.CommentInfo
+	for i, rev := range fs.Args() {
+		comments, _, err := scanSuppressed(func(match string) ([]lexer.CommentInfo, error) {
+			return lexer.ScanRevision(*repo, rev, match)
+		}, *match)
+		if err != nil {
+			return err
+		}
+		scans[i] = comments
+	}
+	return lexer.WriteChanges(os.Stdout, *format, lexer.CompareComments(scans[0], scans[1]))
+}
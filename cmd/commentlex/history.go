@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func historyCmd(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	repo := fs.String("repo", ".", "path of the git repository, may be a bare repository")
+	rev := fs.String("rev", "HEAD", "newest revision of the history to walk")
+	every := fs.Int("every", 1, "only scan every Nth commit")
+	days := fs.Int("days", 0, "only scan one commit per this many days")
+	match := fs.String("match", "", "only count comments matching this string, see Scanner.Match")
+	format := fs.String("format", "csv", "output format: csv, json")
+	fs.Parse(args)
+
+	points, err := lexer.History(*repo, *rev, lexer.HistoryOptions{
+		Every:    *every,
+		Interval: time.Duration(*days) * 24 * time.Hour,
+		Match:    *match,
+	})
+	if err != nil {
+		return err
+	}
+	return lexer.WriteHistory(os.Stdout, *format, points)
+}
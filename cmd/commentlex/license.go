@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func licenseCmd(args []string) error {
+	fs := flag.NewFlagSet("license", flag.ExitOnError)
+	template := fs.String("template", "", "file holding the header text, {year} and {author} are placeholders")
+	year := fs.String("year", "", "year written for {year}, defaults to the current year")
+	author := fs.String("author", "", "author written for {author}")
+	block := fs.Bool("block", false, "write block comments where the language has them")
+	diff := fs.Bool("diff", false, "print a diff of the fixed headers")
+	write := fs.Bool("w", false, "write the fixed headers to the files")
+	rewrites := addExtensionlessFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: commentlex license -template file [flags] [path ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *template == "" {
+		fs.Usage()
+		return errors.New("license needs -template")
+	}
+	text, err := os.ReadFile(*template)
+	if err != nil {
+		return err
+	}
+	h := &lexer.LicenseHeader{Template: string(text), Year: *year, Author: *author, Block: *block}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	failed := 0
+	err = lexer.WalkPaths(paths, func(file string) error {
+		if !rewrites(file) {
+			return nil
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := h.Check(file, src); err == nil {
+			return nil
+		} else if !*diff && !*write {
+			fmt.Printf("%s: %v\n", file, err)
+			failed++
+			return nil
+		}
+		fixed, err := h.Fix(file, src)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		if *diff {
+			if err := lexer.WriteUnifiedDiff(os.Stdout, file, src, fixed); err != nil {
+				return err
+			}
+		}
+		if !*write {
+			return nil
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, fixed, info.Mode())
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d files without a valid licence header", failed)
+	}
+	return nil
+}
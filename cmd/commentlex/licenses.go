@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func licensesCmd(args []string) error {
+	fs := flag.NewFlagSet("licenses", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Parse(args)
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	comments, err := lexer.ScanPaths(paths, "")
+	if err != nil {
+		return err
+	}
+	return lexer.WriteInventory(os.Stdout, *format, lexer.NewInventory(comments))
+}
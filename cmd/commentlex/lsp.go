@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func lspCmd(args []string) error {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	rules := fs.String("rules", "", "JSON file of rules reported as diagnostics, see lexer.ParseRules")
+	tags := fs.String("tags", "", "comma separated tags of comments listed as symbols, defaults to "+strings.Join(lexer.DefaultTags, ","))
+	root := fs.String("root", "", "workspace directory, defaults to the root of the client")
+	fs.Parse(args)
+
+	l := &lexer.LSPServer{Root: *root}
+	if *rules != "" {
+		var err error
+		if l.Rules, err = lexer.LoadRules(*rules); err != nil {
+			return err
+		}
+	}
+	if *tags != "" {
+		l.Tags = strings.Split(*tags, ",")
+	}
+	return l.Serve(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,100 @@
+// Command commentlex reports the comments found in source files.
+//
+// Usage:
+//
+//	commentlex <command> [flags] [path ...]
+//
+// Run commentlex help to list the commands and commentlex <command> -h for
+// the flags of a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+// commands maps a command name to the function running it. Each function
+// gets the arguments following the command name.
+var commands = map[string]func(args []string) error{
+	"cache":        cacheCmd,
+	"convert":      convertCmd,
+	"copyright":    copyrightCmd,
+	"diff":         diffCmd,
+	"history":      historyCmd,
+	"install-hook": installHookCmd,
+	"license":      licenseCmd,
+	"licenses":     licensesCmd,
+	"lsp":          lspCmd,
+	"reflow":       reflowCmd,
+	"replace":      replaceCmd,
+	"scan":         scanCmd,
+	"serve":        serveCmd,
+	"staged":       stagedCmd,
+	"strip":        stripCmd,
+	"watch":        watchCmd,
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" {
+		usage()
+		return
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "commentlex: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "commentlex:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: commentlex <command> [flags] [path ...]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "\t"+name)
+	}
+}
+
+// addExtensionlessFlag adds the flag choosing whether commands rewriting files
+// rewrite files without an extension to fs, and returns a function reporting
+// whether a file is rewritten once fs is parsed. Files such as LICENSE or
+// Makefile have no extension but their comment syntax is only guessed.
+func addExtensionlessFlag(fs *flag.FlagSet) func(file string) bool {
+	all := fs.Bool("extensionless", false, "also rewrite files without an extension, such as LICENSE or Makefile")
+	return func(file string) bool {
+		return *all || filepath.Ext(file) != ""
+	}
+}
+
+// scanSuppressed calls scan with match and removes the comments suppressed by
+// commentlex: directives. It also returns the directives suppressing nothing.
+// Directives do not need to match, so scan is called a second time without
+// match to find them if match is set.
+func scanSuppressed(scan func(match string) ([]lexer.CommentInfo, error), match string) (comments, unused []lexer.CommentInfo, err error) {
+	comments, err = scan(match)
+	if err != nil {
+		return nil, nil, err
+	}
+	var all []lexer.CommentInfo
+	if match != "" {
+		if all, err = scan(""); err != nil {
+			return nil, nil, err
+		}
+	}
+	comments, unused = lexer.Suppress(comments, all)
+	return comments, unused, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func reflowCmd(args []string) error {
+	fs := flag.NewFlagSet("reflow", flag.ExitOnError)
+	width := fs.Int("width", 100, "maximum width of a line in columns")
+	tab := fs.Int("tab", 4, "columns of a tab")
+	write := fs.Bool("w", false, "write the changes to the files instead of printing a diff")
+	rewrites := addExtensionlessFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: commentlex reflow [flags] [path ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	return lexer.WalkPaths(paths, func(file string) error {
+		if !rewrites(file) {
+			return nil
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		out, n, err := lexer.Reflower{Width: *width, TabWidth: *tab}.Reflow(file, src)
+		if err != nil || n == 0 {
+			return err
+		}
+		if !*write {
+			return lexer.WriteUnifiedDiff(os.Stdout, file, src, out)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, out, info.Mode())
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func replaceCmd(args []string) error {
+	fs := flag.NewFlagSet("replace", flag.ExitOnError)
+	pattern := fs.String("pattern", "", "regular expression to replace in comments")
+	replace := fs.String("replace", "", "replacement, $1 or ${name} insert submatches")
+	match := fs.String("match", "", "only replace in comments matching this string, see Scanner.Match")
+	write := fs.Bool("w", false, "write the changes to the files instead of printing a diff")
+	rewrites := addExtensionlessFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: commentlex replace -pattern regexp -replace text [flags] [path ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *pattern == "" {
+		fs.Usage()
+		return errors.New("replace needs -pattern")
+	}
+	re, err := regexp.Compile(*pattern)
+	if err != nil {
+		return err
+	}
+	r := &lexer.Replacer{Pattern: re, Replace: *replace, Match: *match}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	total, files := 0, 0
+	err = lexer.WalkPaths(paths, func(file string) error {
+		if !rewrites(file) {
+			return nil
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		out, n, err := r.ReplaceSource(file, src)
+		if err != nil || n == 0 {
+			return err
+		}
+		total, files = total+n, files+1
+		if !*write {
+			return lexer.WriteUnifiedDiff(os.Stdout, file, src, out)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, out, info.Mode())
+	})
+	if err != nil {
+		return err
+	}
+	if *write {
+		fmt.Fprintf(os.Stderr, "replaced %d matches in %d files\n", total, files)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func scanCmd(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	match := fs.String("match", "", "only report comments matching this string, see Scanner.Match")
+	format := fs.String("format", "text", "output format: "+strings.Join(lexer.ReportFormats, ", "))
+	blame := fs.Bool("blame", false, "add the author and date of each comment from git blame")
+	owners := fs.String("codeowners", "", "assign owners from the CODEOWNERS file of the repository at this directory")
+	owner := fs.String("owner", "", "only report comments owned by this owner, implies -codeowners with the repository of the paths")
+	group := fs.Bool("group-by-owner", false, "group the report by owner, implies -codeowners with the repository of the paths")
+	baseline := fs.String("baseline", "", "only report comments not recorded in this baseline file and fail if there are any")
+	update := fs.Bool("update-baseline", false, "record all comments found in the -baseline file instead of reporting them")
+	ratchet := fs.Bool("ratchet", false, "lower the counts in the -baseline file when comments were removed")
+	budgets := fs.String("budgets", "", "report the budgets in this file instead of the comments and fail if one is exceeded")
+	unused := fs.Bool("unused-suppressions", false, "report the commentlex: directives that suppress nothing instead of the comments")
+	diff := fs.String("diff", "", "only report comments on lines added by this unified diff file, - reads standard input")
+	diffBase := fs.String("diff-base", "", "only report comments on lines changed since this git revision")
+	repo := fs.String("repo", ".", "git repository to read -rev from, may be a bare repository")
+	rev := fs.String("rev", "", "scan this git revision instead of the files on disk, paths are then relative to the root of the tree")
+	useCache := fs.Bool("cache", false, "reuse the comments of files scanned before from the on-disk cache")
+	cacheFlags := addCacheFlags(fs)
+	fs.Parse(args)
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	scan := func(match string) ([]lexer.CommentInfo, error) {
+		if *rev != "" {
+			return lexer.ScanRevision(*repo, *rev, match, fs.Args()...)
+		}
+		if *useCache {
+			cache, err := cacheFlags()
+			if err != nil {
+				return nil, err
+			}
+			return cache.ScanPaths(paths, match)
+		}
+		return lexer.ScanPaths(paths, match)
+	}
+	comments, stale, err := scanSuppressed(scan, *match)
+	if err != nil {
+		return err
+	}
+	if *diff != "" || *diffBase != "" {
+		changed, err := changedLines(*diff, *diffBase)
+		if err != nil {
+			return err
+		}
+		comments = changed.Filter(comments)
+	}
+	if *unused {
+		return lexer.WriteReport(os.Stdout, *format, stale)
+	}
+	if *blame {
+		var b lexer.Blamer
+		b.Annotate(comments)
+	}
+	if *owners != "" || *owner != "" || *group {
+		co, err := loadCodeowners(paths, *owners, *repo, *rev)
+		if err != nil {
+			return err
+		}
+		co.Assign(comments)
+	}
+	if *owner != "" {
+		comments = lexer.FilterByOwner(comments, *owner)
+	}
+
+	if *budgets != "" {
+		return checkBudgets(*budgets, *format, comments)
+	}
+
+	var added int
+	if *baseline != "" {
+		if *update {
+			return lexer.NewBaseline(comments).Save(*baseline)
+		}
+		b, err := lexer.LoadBaseline(*baseline)
+		if err != nil {
+			return err
+		}
+		if *ratchet && b.Ratchet(comments) {
+			if err := b.Save(*baseline); err != nil {
+				return err
+			}
+		}
+		comments = b.Filter(comments)
+		added = len(comments)
+	}
+	if *group {
+		err = lexer.WriteGroups(os.Stdout, *format, lexer.GroupByOwner(comments))
+	} else {
+		err = lexer.WriteReport(os.Stdout, *format, comments)
+	}
+	if err == nil && added > 0 {
+		err = fmt.Errorf("%d comments not in baseline %s", added, *baseline)
+	}
+	return err
+}
+
+// changedLines reads the lines changed by the diff in file, or by the
+// working tree compared to the git revision base.
+func changedLines(file string, base string) (lexer.ChangedLines, error) {
+	switch {
+	case base != "":
+		return lexer.GitDiff(".", base)
+	case file == "-":
+		return lexer.ParseDiff(os.Stdin)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return lexer.ParseDiff(f)
+}
+
+// checkBudgets writes the state of the budgets in file and fails if any of
+// them is exceeded.
+func checkBudgets(file string, format string, comments []lexer.CommentInfo) error {
+	budgets, err := lexer.LoadBudgets(file)
+	if err != nil {
+		return err
+	}
+	results := lexer.CheckBudgets(budgets, comments)
+	if err := lexer.WriteBudgets(os.Stdout, format, results); err != nil {
+		return err
+	}
+	exceeded := 0
+	for _, r := range results {
+		if r.Exceeded() {
+			exceeded++
+		}
+	}
+	if exceeded > 0 {
+		return fmt.Errorf("%d of %d budgets exceeded", exceeded, len(results))
+	}
+	return nil
+}
+
+// loadCodeowners reads the CODEOWNERS file of the repository at dir or, when
+// dir is "", the one assigning the owners of the comments in paths, or in the
+// tree of rev in repo.
+func loadCodeowners(paths []string, dir, repo, rev string) (*lexer.Codeowners, error) {
+	switch {
+	case dir != "":
+		return lexer.LoadCodeowners(dir)
+	case rev != "":
+		return lexer.LoadRevisionCodeowners(repo, rev)
+	}
+	root := ""
+	for _, p := range paths {
+		r, err := lexer.CodeownersRoot(p)
+		if err != nil {
+			return nil, err
+		}
+		if root != "" && r != root {
+			return nil, fmt.Errorf("%s and %s are in different repositories, choose one with -codeowners", root, r)
+		}
+		root = r
+	}
+	return lexer.LoadCodeowners(root)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func serveCmd(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	repo := fs.String("repo", "", "directory to scan for GET /comments")
+	match := fs.String("match", "", "only keep comments of -repo matching this string, see Scanner.Match")
+	refresh := fs.Duration("refresh", 5*time.Minute, "time between scans of -repo")
+	maxBody := fs.Int64("max-body", 1<<20, "largest accepted request body in bytes")
+	maxScans := fs.Int("max-scans", 0, "scans running at the same time, defaults to the number of CPUs")
+	timeout := fs.Duration("timeout", 30*time.Second, "time limit of a request")
+	fs.Parse(args)
+
+	s := &lexer.Server{Repo: *repo, Match: *match, MaxBodySize: *maxBody, MaxConcurrent: *maxScans}
+	if err := s.Rescan(); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *repo != "" && *refresh > 0 {
+		go func() {
+			tick := time.NewTicker(*refresh)
+			defer tick.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick.C:
+					if err := s.Rescan(); err != nil {
+						log.Println("commentlex: rescan:", err)
+					}
+				}
+			}
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           http.TimeoutHandler(s, *timeout, `{"Error":"timeout"}`),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       *timeout,
+		WriteTimeout:      *timeout + 5*time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	log.Println("commentlex: listening on", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+// stagedFlags lists the flags of the staged command that install-hook passes
+// on to it.
+var stagedFlags = []string{"match", "format"}
+
+// addStagedFlags adds the flags of the staged command to fs.
+func addStagedFlags(fs *flag.FlagSet) (match, format *string) {
+	match = fs.String("match", "", "only report comments matching this string, see Scanner.Match")
+	format = fs.String("format", "text", "output format: "+strings.Join(lexer.ReportFormats, ", "))
+	return match, format
+}
+
+func stagedCmd(args []string) error {
+	fs := flag.NewFlagSet("staged", flag.ExitOnError)
+	match, format := addStagedFlags(fs)
+	fs.Parse(args)
+
+	comments, _, err := scanSuppressed(func(match string) ([]lexer.CommentInfo, error) {
+		return lexer.ScanStaged(".", match)
+	}, *match)
+	if err != nil {
+		return err
+	}
+	if err := lexer.WriteReport(os.Stdout, *format, comments); err != nil {
+		return err
+	}
+	if len(comments) > 0 {
+		return fmt.Errorf("%d comments in staged changes", len(comments))
+	}
+	return nil
+}
+
+func installHookCmd(args []string) error {
+	fs := flag.NewFlagSet("install-hook", flag.ExitOnError)
+	repo := fs.String("repo", ".", "path of the git repository")
+	force := fs.Bool("force", false, "replace an existing pre-commit hook")
+	addStagedFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: commentlex install-hook [flags]")
+		fmt.Fprintln(fs.Output(), "The hook runs commentlex staged with the -match and -format flags given.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var staged []string
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range stagedFlags {
+			if f.Name == name {
+				staged = append(staged, "-"+name+"="+f.Value.String())
+			}
+		}
+	})
+	command := "commentlex staged"
+	for _, arg := range append(staged, fs.Args()...) {
+		command += " '" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	hook, err := lexer.InstallHook(*repo, command, *force)
+	if err != nil {
+		return err
+	}
+	fmt.Println("installed", hook)
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func stripCmd(args []string) error {
+	fs := flag.NewFlagSet("strip", flag.ExitOnError)
+	lang := fs.String("lang", "", "file extension choosing the comment syntax, required when reading stdin")
+	preserve := fs.Bool("preserve", false, "replace comments with spaces so lines and columns stay the same")
+	keepLicense := fs.Bool("keep-license", false, "keep a licence or copyright header")
+	keepDirectives := fs.Bool("keep-directives", false, "keep comments holding tool directives such as //go:build")
+	write := fs.Bool("w", false, "write the result to the files instead of stdout")
+	rewrites := addExtensionlessFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: commentlex strip [flags] [file ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	s := lexer.Stripper{Preserve: *preserve, KeepLicense: *keepLicense, KeepDirectives: *keepDirectives}
+
+	if fs.NArg() == 0 {
+		if *lang == "" {
+			return errors.New("strip needs -lang when reading stdin")
+		}
+		if *write {
+			return errors.New("strip cannot use -w when reading stdin")
+		}
+		return s.Strip(os.Stdout, os.Stdin, *lang)
+	}
+	for _, file := range fs.Args() {
+		ext := *lang
+		if ext == "" {
+			if !rewrites(file) {
+				continue
+			}
+			ext = filepath.Ext(file)
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		err = s.Strip(&out, f, ext)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if !*write {
+			if _, err := os.Stdout.Write(out.Bytes()); err != nil {
+				return err
+			}
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, out.Bytes(), info.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+// patterns is a flag collecting every value it is given.
+type patterns []string
+
+func (p *patterns) String() string     { return strings.Join(*p, ",") }
+func (p *patterns) Set(v string) error { *p = append(*p, v); return nil }
+
+func watchCmd(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	match := fs.String("match", "", "only report comments matching this string, see Scanner.Match")
+	debounce := fs.Duration("debounce", 100*time.Millisecond, "time without changes before changed files are scanned")
+	var ignore patterns
+	fs.Var(&ignore, "ignore", "gitignore style pattern of paths to skip in addition to .gitignore, may be repeated")
+	fs.Parse(args)
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	ig, err := lexer.LoadIgnore(root, ignore...)
+	if err != nil {
+		return err
+	}
+	w := &lexer.Watcher{Match: *match, Debounce: *debounce, Ignore: ig}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// one JSON object per line and change
+	enc := json.NewEncoder(os.Stdout)
+	return w.Watch(ctx, root, func(changes []lexer.CommentChange) error {
+		for _, c := range changes {
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
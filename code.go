@@ -0,0 +1,62 @@
+package lexer
+
+import (
+	"bytes"
+	"io"
+)
+
+// segment is a piece of source returned in ScanCode mode.
+type segment struct {
+	tok  rune // Code or Comment
+	text string
+	pos  Position
+}
+
+// scanSegment returns the next segment of the source in ScanCode mode. The
+// source is split into Comment tokens holding exactly the text of a comment,
+// as returned by Comments, and Code tokens holding everything in between,
+// including white space. Code tokens that would be empty are not returned.
+// Comments not matching Match are part of the code.
+func (s *Scanner) scanSegment() rune {
+	if s.segments == nil {
+		s.segments = s.readSegments()
+	}
+	if s.segNum >= len(s.segments) {
+		s.segNum = len(s.segments) + 1
+		s.Position = s.segEnd
+		s.Line = 0
+		return EOF
+	}
+	seg := s.segments[s.segNum]
+	s.segNum++
+	s.Offset, s.Line, s.Column = seg.pos.Offset, seg.pos.Line, seg.pos.Column
+	s.segEnd = advance(seg.pos, seg.text)
+	s.segEnd.Filename = s.Filename
+	return seg.tok
+}
+
+// readSegments reads the rest of the source and splits it into segments.
+func (s *Scanner) readSegments() []segment {
+	src, err := io.ReadAll(s.src)
+	if err != nil {
+		s.error(err.Error())
+	}
+	var comments Scanner
+	comments.Match = s.Match
+	comments.InitReader("input"+s.srcType, bytes.NewReader(src))
+	segments := []segment{}
+	pos := Position{Line: 1, Column: 1}
+	for _, c := range comments.Comments("") {
+		if c.Pos.Offset > pos.Offset {
+			code := string(src[pos.Offset:c.Pos.Offset])
+			segments = append(segments, segment{tok: Code, text: code, pos: pos})
+		}
+		segments = append(segments, segment{tok: Comment, text: c.Text, pos: c.Pos})
+		pos = c.End
+	}
+	if pos.Offset < len(src) {
+		segments = append(segments, segment{tok: Code, text: string(src[pos.Offset:]), pos: pos})
+	}
+	s.segEnd = Position{Filename: s.Filename, Line: 1, Column: 1}
+	return segments
+}
@@ -0,0 +1,249 @@
+package lexer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// codeownersPaths lists where LoadCodeowners looks for a CODEOWNERS file
+// relative to the repository root, in the same order GitHub does.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Codeowners holds the rules of a CODEOWNERS file and assigns owners to the
+// files of the repository it belongs to.
+type Codeowners struct {
+	root  string
+	tree  bool // file names are paths in a git tree rather than on disk
+	rules []ownerRule
+}
+
+type ownerRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// LoadCodeowners reads the CODEOWNERS file of the repository at root, looking
+// in .github/, the root itself and docs/ in that order. The returned error
+// wraps fs.ErrNotExist when none of them exists.
+func LoadCodeowners(root string) (*Codeowners, error) {
+	for _, p := range codeownersPaths {
+		f, err := os.Open(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		return ParseCodeowners(root, f)
+	}
+	return nil, fmt.Errorf("no CODEOWNERS file in %s: %w", root, fs.ErrNotExist)
+}
+
+// LoadRevisionCodeowners reads the CODEOWNERS file of revision rev of the git
+// repository at repo like LoadCodeowners, without checking it out. The owners
+// are assigned to comments named by their paths in the tree, such as those of
+// ScanRevision. The returned error wraps fs.ErrNotExist when the tree holds no
+// CODEOWNERS file.
+func LoadRevisionCodeowners(repo string, rev string) (*Codeowners, error) {
+	for _, p := range codeownersPaths {
+		data, err := git(repo, "show", rev+":"+p)
+		if err != nil {
+			continue
+		}
+		c, err := ParseCodeowners(repo, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		c.tree = true
+		return c, nil
+	}
+	return nil, fmt.Errorf("no CODEOWNERS file in %s of %s: %w", rev, repo, fs.ErrNotExist)
+}
+
+// CodeownersRoot returns the root of the repository holding path, the closest
+// directory at or above it with a .git entry. Outside of a git repository it
+// is the closest directory holding a CODEOWNERS file where LoadCodeowners
+// looks for one. The returned error wraps fs.ErrNotExist when there is
+// neither.
+func CodeownersRoot(path string) (string, error) {
+	start, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(start); err == nil && !info.IsDir() {
+		start = filepath.Dir(start)
+	}
+	for _, marks := range [][]string{{".git"}, codeownersPaths} {
+		for dir := start; ; dir = filepath.Dir(dir) {
+			for _, m := range marks {
+				if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(m))); err == nil {
+					return dir, nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	return "", fmt.Errorf("no repository or CODEOWNERS file holds %s: %w", path, fs.ErrNotExist)
+}
+
+// ParseCodeowners parses the CODEOWNERS rules in r for the repository at root.
+func ParseCodeowners(root string, r io.Reader) (*Codeowners, error) {
+	c := &Codeowners{root: root}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		pattern, err := ownerPattern(strings.TrimPrefix(fields[0], "\\"))
+		if err != nil {
+			return nil, fmt.Errorf("CODEOWNERS line %d: %v", n, err)
+		}
+		c.rules = append(c.rules, ownerRule{pattern: pattern, owners: fields[1:]})
+	}
+	return c, sc.Err()
+}
+
+// ownerPattern converts a CODEOWNERS path pattern into a regular expression
+// matching slash separated paths relative to the repository root. Patterns
+// follow the gitignore rules used by GitHub: a pattern without a slash matches
+// at any depth, a pattern matching a directory matches everything below it,
+// * and ? do not match a slash but ** does.
+func ownerPattern(p string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(p, "/")
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	p = strings.Trim(p, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case p[i] == '*':
+			re.WriteString("[^/]*")
+		case p[i] == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	last := p[strings.LastIndexByte(p, '/')+1:]
+	switch {
+	case dirOnly:
+		re.WriteString("/.*")
+	case last != "*" || !anchored:
+		// a directory owns everything below it, but dir/* only its direct children
+		re.WriteString("(?:/.*)?")
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
+
+// Owners returns the owners of path, which is relative to the repository
+// root. As in GitHub the last matching rule wins. Paths without owners
+// return nil.
+func (c *Codeowners) Owners(path string) []string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(path) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// rel returns path relative to the repository root.
+func (c *Codeowners) rel(path string) (string, error) {
+	if c.tree {
+		return path, nil
+	}
+	root, err := filepath.Abs(c.root)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside of %s", path, c.root)
+	}
+	return rel, nil
+}
+
+// Assign sets the Owners field of each comment from the file it is in.
+// Comments in files outside of the repository get no owners.
+func (c *Codeowners) Assign(comments []CommentInfo) {
+	for i := range comments {
+		comments[i].Owners = nil
+		if rel, err := c.rel(comments[i].Pos.Filename); err == nil {
+			comments[i].Owners = c.Owners(rel)
+		}
+	}
+}
+
+// GroupByOwner groups comments by their Owners field. A comment with several
+// owners is in the group of each of them and comments without owners are
+// grouped under the empty string.
+func GroupByOwner(comments []CommentInfo) map[string][]CommentInfo {
+	groups := make(map[string][]CommentInfo)
+	for _, c := range comments {
+		if len(c.Owners) == 0 {
+			groups[""] = append(groups[""], c)
+		}
+		for _, o := range c.Owners {
+			groups[o] = append(groups[o], c)
+		}
+	}
+	return groups
+}
+
+// FilterByOwner returns the comments owned by owner. An empty owner selects
+// the comments without owners.
+func FilterByOwner(comments []CommentInfo, owner string) []CommentInfo {
+	var owned []CommentInfo
+	for _, c := range comments {
+		if owner == "" && len(c.Owners) == 0 {
+			owned = append(owned, c)
+			continue
+		}
+		for _, o := range c.Owners {
+			if strings.EqualFold(o, owner) {
+				owned = append(owned, c)
+				break
+			}
+		}
+	}
+	return owned
+}
+
+// groupNames returns the sorted keys of groups.
+func groupNames(groups map[string][]CommentInfo) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,122 @@
+package lexer_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestCodeownersPatterns(t *testing.T) {
+	rules := `# global owner
+* @everyone
+*.js @js-team
+/build/logs/ @ops
+docs/* @writers
+apps/ @apps # trailing comment
+**/payments @billing
+`
+	co, err := lexer.ParseCodeowners("", strings.NewReader(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ""
+	for _, path := range []string{"main.go", "web/app.js", "build/logs/out.txt", "docs/index.md", "docs/api/index.md", "src/apps/x.go", "pkg/payments/pay.go"} {
+		res += path + "=" + strings.Join(co.Owners(path), ",") + " "
+	}
+
+	want := "main.go=@everyone web/app.js=@js-team build/logs/out.txt=@ops docs/index.md=@writers docs/api/index.md=@everyone src/apps/x.go=@apps pkg/payments/pay.go=@billing "
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("CODEOWNERS patterns not matched as expected")
+	}
+}
+
+func TestCodeownersGroupAndFilter(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".github/CODEOWNERS": "* @core\napi/ @api-team\n",
+		"main.go":            "//@todo core work\n",
+		"api/api.go":         "//@todo api work\n//@todo more api work\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	co, err := lexer.LoadCodeowners(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, err := lexer.ScanPaths([]string{root}, "@todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	co.Assign(comments)
+
+	groups := lexer.GroupByOwner(comments)
+	if len(groups["@core"]) != 1 || len(groups["@api-team"]) != 2 {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	if api := lexer.FilterByOwner(comments, "@API-team"); len(api) != 2 || api[0].Text != "//@todo api work" {
+		t.Fatalf("unexpected filter result %v", api)
+	}
+}
+
+func TestCodeownersRoot(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"repo/.git/HEAD", "repo/docs/CODEOWNERS", "repo/docs/a.md", "tree/CODEOWNERS", "tree/sub/x.go"} {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, want := range map[string]string{"repo/docs/a.md": "repo", "repo/docs": "repo", "tree/sub/x.go": "tree"} {
+		root, err := lexer.CodeownersRoot(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != filepath.Join(dir, want) {
+			t.Errorf("%s: got root %s, want %s", path, root, want)
+		}
+	}
+}
+
+func TestLoadRevisionCodeowners(t *testing.T) {
+	dir := gitRepo(t, map[string]string{".github/CODEOWNERS": "/pkg/ @pkg-team\n", "pkg/a.go": "// TODO pkg\n", "b.go": "// TODO root\n"})
+	gitRun(t, dir, "tag", "v1")
+	// the working tree is not read
+	if err := os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bare := filepath.Join(t.TempDir(), "bare.git")
+	gitRun(t, dir, "clone", "-q", "--bare", dir, bare)
+
+	co, err := lexer.LoadRevisionCodeowners(bare, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, err := lexer.ScanRevision(bare, "v1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	co.Assign(comments)
+	groups := lexer.GroupByOwner(comments)
+	if len(groups["@pkg-team"]) != 1 || groups["@pkg-team"][0].Pos.Filename != "pkg/a.go" || len(groups[""]) != 1 {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+
+	if _, err := lexer.LoadRevisionCodeowners(gitRepo(t, map[string]string{"a.go": "\n"}), "HEAD"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package lexer
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// CommentInfo is a single comment found in a source file.
+// Pos.Filename and End.Filename hold the name of the file the comment is in.
+type CommentInfo struct {
+	Text string   // comment text including the comment characters
+	Pos  Position // position of the first comment character
+	End  Position // position immediately after the comment
+
+	// Blame is set by a Blamer when the comment is in a git repository.
+	Blame *BlameInfo `json:",omitempty"`
+	// Owners is set by Codeowners.Assign to the owners of the file.
+	Owners []string `json:",omitempty"`
+}
+
+// Comments scans the rest of the source and returns every comment found.
+// Unlike TokenText the text of each comment is trimmed to the comment
+// itself, so code preceding an inline comment is not part of it. Comments
+// following a block comment on its line are returned as well.
+func (s *Scanner) Comments(file string) []CommentInfo {
+	var comments []CommentInfo
+	for tok := s.Scan(); tok != EOF; tok = s.Scan() {
+		if tok != Comment {
+			continue
+		}
+		var found []CommentInfo
+		text, pos := s.TokenText(), s.Position
+		for {
+			start, end := findComment(s.srcType, text)
+			if start < 0 {
+				if len(found) > 0 {
+					break
+				}
+				start, end = 0, len(text)
+			}
+			// a literal spanning lines may precede the comment
+			pos = advance(pos, text[:start])
+			pos.Filename = file
+			c := CommentInfo{Text: text[start:end], Pos: pos, End: advance(pos, text[start:end])}
+			found = append(found, c)
+			if end == len(text) {
+				break
+			}
+			text, pos = text[end:], c.End
+		}
+		// the line matched, but a comment following another may be the one matching
+		if s.Match != "" && len(found) > 1 {
+			var matching []CommentInfo
+			for _, c := range found {
+				if commentMatches(s.srcType, c.Text, s.Match) {
+					matching = append(matching, c)
+				}
+			}
+			if len(matching) > 0 {
+				found = matching
+			}
+		}
+		comments = append(comments, found...)
+	}
+	return comments
+}
+
+// commentMatches reports whether the comment text of a file with extension ext
+// matches match like Scanner.Match: a line comment has to start with match,
+// spaces aside, and a block comment has to contain it.
+func commentMatches(ext, text, match string) bool {
+	for _, v := range syntaxFor(ext) {
+		if v.startMulti != "" && strings.HasPrefix(text, v.startMulti) {
+			return strings.Contains(text, match)
+		}
+	}
+	for _, v := range syntaxFor(ext) {
+		if v.startSingle != "" && strings.HasPrefix(text, v.startSingle) {
+			return strings.HasPrefix(strings.ReplaceAll(text, " ", ""), v.startSingle+strings.ReplaceAll(match, " ", ""))
+		}
+	}
+	return strings.Contains(text, match)
+}
+
+// Body returns the text of c without the comment characters, the gutters of
+// the lines of a block comment such as " * ", the indentation common to its
+// lines, trailing white space and leading and trailing empty lines.
+func (c CommentInfo) Body() string {
+	return strings.Join(commentBody(c), "\n")
+}
+
+// commentBody returns the lines of the body of c without comment characters,
+// gutter, leading and trailing empty lines and the indentation common to the
+// lines after the first.
+func commentBody(c CommentInfo) []string {
+	lines := bodyLines(c)
+	if len(lines) > 0 {
+		lines[0] = strings.TrimLeft(lines[0], " \t")
+	}
+	common := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= common && common > 0 {
+			lines[i] = lines[i][common:]
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Body returns the text of the comment most recently scanned normalized like
+// CommentInfo.Body, without the code preceding it and its comment characters.
+// Valid after Scan returned Comment.
+func (s *Scanner) Body() string {
+	text := s.TokenText()
+	start, end := commentBounds(s.srcType, text)
+	c := CommentInfo{Text: text[start:end]}
+	c.Pos.Filename = "input" + s.srcType
+	return c.Body()
+}
+
+// ReadComments scans src, which holds the contents of file, and returns the
+// comments found in it. When match is not empty only comments matching it are
+// returned, see Scanner.Match.
+func ReadComments(file string, src io.Reader, match string) []CommentInfo {
+	var s Scanner
+	s.Match = match
+	s.InitReader(file, src)
+	return s.Comments(file)
+}
+
+// ScanFile opens file and returns the comments found in it, see ReadComments.
+func ScanFile(file string, match string) ([]CommentInfo, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadComments(file, f, match), nil
+}
+
+// ScanPaths scans every file in paths and returns the comments found in them.
+// Directories are walked recursively, skipping .git directories and files
+// with an extension that is not supported.
+func ScanPaths(paths []string, match string) ([]CommentInfo, error) {
+	var comments []CommentInfo
+	err := WalkPaths(paths, func(file string) error {
+		found, err := ScanFile(file, match)
+		comments = append(comments, found...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// WalkPaths calls fn for every file in paths that ScanPaths scans.
+func WalkPaths(paths []string, fn func(file string) error) error {
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if path != root && !Supported(path) {
+				return nil
+			}
+			return fn(path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Supported reports whether file has an extension listed in Extensions.
+func Supported(file string) bool {
+	return len(syntaxFor(filepath.Ext(file))) > 0
+}
+
+// syntaxFor returns the comment characters used by files with extension ext.
+func syntaxFor(ext string) []CommentValues {
+	var found []CommentValues
+	for _, v := range Extensions {
+		for _, e := range v.ext {
+			if e == ext {
+				found = append(found, v)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// codeIndex returns the index of the first sub in text that is not inside a
+// literal started by one of quotes, see Quotes, or -1.
+func codeIndex(text, sub, quotes string) int {
+	for i := 0; i < len(text); i++ {
+		if strings.HasPrefix(text[i:], sub) {
+			return i
+		}
+		quote := text[i]
+		if quote >= utf8.RuneSelf || strings.IndexByte(quotes, quote) < 0 {
+			continue
+		}
+		for i++; i < len(text) && text[i] != quote; i++ {
+			if quote == '`' {
+				continue
+			}
+			if text[i] == '\\' && i+1 < len(text) && text[i+1] != '\n' {
+				i++
+			} else if text[i] == '\n' || text[i] == '\\' {
+				break
+			}
+		}
+	}
+	return -1
+}
+
+// commentBounds returns where the comment starts and ends in the text of a
+// Comment token. The token text starts at the beginning of the line, so the
+// earliest comment characters for the file type mark the start of the comment.
+// If those start a multi line comment the comment ends with its end characters.
+// Without comment characters the whole text is the comment.
+func commentBounds(ext string, text string) (start, end int) {
+	if start, end = findComment(ext, text); start < 0 {
+		return 0, len(text)
+	}
+	return start, end
+}
+
+// findComment returns where the first comment in text starts and ends, see
+// commentBounds, or -1 when text holds no comment characters.
+func findComment(ext string, text string) (start, end int) {
+	start, end = -1, len(text)
+	var opening, closing string
+	for _, v := range syntaxFor(ext) {
+		for _, open := range []string{v.startSingle, v.startMulti} {
+			if open == "" {
+				continue
+			}
+			i := codeIndex(text, open, Quotes[ext])
+			if i < 0 || start >= 0 && i > start {
+				continue
+			}
+			if i == start && open != v.startMulti {
+				// keep the longer multi line start, e.g. --[[ over --
+				continue
+			}
+			start, opening, closing = i, open, ""
+			if open == v.startMulti {
+				closing = v.endMulti
+			}
+		}
+	}
+	if start < 0 {
+		return -1, -1
+	}
+	if closing != "" {
+		body := start + len(opening)
+		if i := strings.Index(text[body:], closing); i >= 0 {
+			end = body + i + len(closing)
+		}
+	} else if i := strings.IndexByte(text[start:], '\n'); i >= 0 {
+		end = start + i
+	}
+	return start, end
+}
+
+// advance returns the position immediately after text when text starts at pos.
+func advance(pos Position, text string) Position {
+	pos.Offset += len(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		pos.Line += strings.Count(text, "\n")
+		pos.Column = utf8.RuneCountInString(text[i+1:]) + 1
+	} else {
+		pos.Column += utf8.RuneCountInString(text)
+	}
+	return pos
+}
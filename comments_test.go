@@ -0,0 +1,86 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestScanFileReturnsCommentPositions(t *testing.T) {
+	comments, err := lexer.ScanFile("tests/test.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ""
+	for _, c := range comments {
+		res += fmt.Sprintf("%d:%d-%d:%d %s|", c.Pos.Line, c.Pos.Column, c.End.Line, c.End.Column, strings.ReplaceAll(c.Text, "\n\t", " "))
+	}
+
+	want := "8:2-8:24 //@todo Single Comment|9:9-9:31 //@test Inline Comment|11:2-13:12 /* Multiline @todo some test Comment */|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("comment positions are not correct")
+	}
+}
+
+func TestReadCommentsUsesFileExtension(t *testing.T) {
+	src := "local a = 1 --@todo lua comment\n--[[ block\n@todo --]]\n"
+	comments := lexer.ReadComments("virtual.lua", strings.NewReader(src), "@todo")
+	res := ""
+	for _, c := range comments {
+		res += c.Pos.Filename + " " + c.Text + "|"
+	}
+
+	want := "virtual.lua --@todo lua comment|virtual.lua --[[ block\n@todo --]]|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("unable to read comments from a reader")
+	}
+}
+
+func TestBody(t *testing.T) {
+	tests := []struct{ file, src, want string }{
+		{"a.go", "x := 1 //  trailing  \n", "trailing"},
+		{"a.go", "/**\n * Doc comment.\n *\n *     code\n */\n", "Doc comment.\n\n    code"},
+		{"a.go", "\t/* first\n\t   second\n\t     third */\n", "first\nsecond\n  third"},
+		{"a.lua", "--[[\n-- one\n--   two\n--]]\n", "one\n  two"},
+		{"a.py", "## hash\n", "hash"},
+		{"a.c", "/*\n#include <a.h>\n#define X 1\n*/\n", "#include <a.h>\n#define X 1"},
+	}
+	for _, test := range tests {
+		comments := lexer.ReadComments(test.file, strings.NewReader(test.src), "")
+		if len(comments) != 1 {
+			t.Fatalf("%s: got %d comments, want 1", test.file, len(comments))
+		}
+		if got := comments[0].Body(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.file, got, test.want)
+		}
+	}
+
+	var s lexer.Scanner
+	s.Mode = lexer.ScanComments
+	s.Init("tests/test.go")
+	var res []string
+	for tok := s.Scan(); tok != lexer.EOF; tok = s.Scan() {
+		if tok == lexer.Comment {
+			res = append(res, s.Body())
+		}
+	}
+	want := "@todo Single Comment|@test Inline Comment|Multiline\n@todo some test\nComment"
+	if got := strings.Join(res, "|"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadCommentsSkipsLiterals(t *testing.T) {
+	src := "s := \"// no\" + `/* no\n// no */` // yes\nr := '/' /* yes */\n"
+	res := ""
+	for _, c := range lexer.ReadComments("a.go", strings.NewReader(src), "") {
+		res += fmt.Sprintf("%d:%d %s|", c.Pos.Line, c.Pos.Column, c.Text)
+	}
+	if want := "2:11 // yes|3:10 /* yes */|"; res != want {
+		t.Fatalf("got %q want %q", res, want)
+	}
+}
@@ -0,0 +1,145 @@
+package lexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The kinds of a CommentChange.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed" // the text of a comment at the same place changed
+	Moved   = "moved"   // the same comment is at another line or in another file
+)
+
+// CommentChange describes how a comment differs between two scans. Old is
+// nil for added comments and New is nil for removed ones.
+type CommentChange struct {
+	Kind string
+	Old  *CommentInfo `json:",omitempty"`
+	New  *CommentInfo `json:",omitempty"`
+}
+
+// CompareComments matches the comments of two scans, such as two revisions
+// of a repository, by fingerprint and returns what changed. Comments with the
+// same fingerprint at the same line are unchanged and not returned.
+func CompareComments(before, after []CommentInfo) []CommentChange {
+	var changes []CommentChange
+	oldLeft := make([]bool, len(before))
+	newLeft := make([]bool, len(after))
+	byPrint := make(map[string][]int)
+	for i, c := range before {
+		oldLeft[i] = true
+		byPrint[Fingerprint(c)] = append(byPrint[Fingerprint(c)], i)
+	}
+
+	// same file and text: unchanged or moved within the file
+	for j, c := range after {
+		newLeft[j] = true
+		fp := Fingerprint(c)
+		if len(byPrint[fp]) == 0 {
+			continue
+		}
+		i := byPrint[fp][0]
+		byPrint[fp] = byPrint[fp][1:]
+		oldLeft[i], newLeft[j] = false, false
+		if before[i].Pos.Line != c.Pos.Line {
+			changes = append(changes, CommentChange{Kind: Moved, Old: &before[i], New: &after[j]})
+		}
+	}
+
+	// same text in another file: moved
+	byText := make(map[string][]int)
+	for i, c := range before {
+		if oldLeft[i] {
+			byText[normalize(c.Text)] = append(byText[normalize(c.Text)], i)
+		}
+	}
+	for j, c := range after {
+		if !newLeft[j] || len(byText[normalize(c.Text)]) == 0 {
+			continue
+		}
+		i := byText[normalize(c.Text)][0]
+		byText[normalize(c.Text)] = byText[normalize(c.Text)][1:]
+		oldLeft[i], newLeft[j] = false, false
+		changes = append(changes, CommentChange{Kind: Moved, Old: &before[i], New: &after[j]})
+	}
+
+	// same place, different text: changed
+	at := make(map[string]int)
+	for i, c := range before {
+		if oldLeft[i] {
+			at[fmt.Sprintf("%s:%d", c.Pos.Filename, c.Pos.Line)] = i
+		}
+	}
+	for j, c := range after {
+		if !newLeft[j] {
+			continue
+		}
+		if i, ok := at[fmt.Sprintf("%s:%d", c.Pos.Filename, c.Pos.Line)]; ok && oldLeft[i] {
+			oldLeft[i], newLeft[j] = false, false
+			changes = append(changes, CommentChange{Kind: Changed, Old: &before[i], New: &after[j]})
+		}
+	}
+
+	for i := range before {
+		if oldLeft[i] {
+			changes = append(changes, CommentChange{Kind: Removed, Old: &before[i]})
+		}
+	}
+	for j := range after {
+		if newLeft[j] {
+			changes = append(changes, CommentChange{Kind: Added, New: &after[j]})
+		}
+	}
+	return changes
+}
+
+// normalize returns text with its white space collapsed.
+func normalize(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// WriteChanges writes the result of CompareComments to w in the given format.
+// The text format prints one line per change, the json format writes the
+// changes as a JSON array and the task formats of WriteReport export the new
+// version of every added, changed or moved comment.
+func WriteChanges(w io.Writer, format string, changes []CommentChange) error {
+	switch format {
+	case "", "text":
+		for _, c := range changes {
+			var line string
+			switch c.Kind {
+			case Added:
+				line = fmt.Sprintf("added %s: %s", c.New.Pos, normalize(c.New.Text))
+			case Removed:
+				line = fmt.Sprintf("removed %s: %s", c.Old.Pos, normalize(c.Old.Text))
+			case Changed:
+				line = fmt.Sprintf("changed %s: %s -> %s", c.New.Pos, normalize(c.Old.Text), normalize(c.New.Text))
+			case Moved:
+				line = fmt.Sprintf("moved %s -> %s: %s", c.Old.Pos, c.New.Pos, normalize(c.New.Text))
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		if changes == nil {
+			changes = []CommentChange{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+	var comments []CommentInfo
+	for _, c := range changes {
+		if c.New != nil {
+			comments = append(comments, *c.New)
+		}
+	}
+	return WriteReport(w, format, comments)
+}
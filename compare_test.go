@@ -0,0 +1,32 @@
+package lexer_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestCompareComments(t *testing.T) {
+	before := lexer.ReadComments("a.go", strings.NewReader("// TODO kept\n// TODO edit me\n// TODO removed\n// TODO to b\n// TODO moves down\n"), "")
+	after := lexer.ReadComments("a.go", strings.NewReader("// TODO kept\n// TODO edited\n\n\n\n// TODO moves down\n// TODO added\n"), "")
+	after = append(after, lexer.ReadComments("b.go", strings.NewReader("// TODO to b\n"), "")...)
+
+	var buf bytes.Buffer
+	if err := lexer.WriteChanges(&buf, "text", lexer.CompareComments(before, after)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `moved a.go:5:1 -> a.go:6:1: // TODO moves down
+moved a.go:4:1 -> b.go:1:1: // TODO to b
+changed a.go:2:1: // TODO edit me -> // TODO edited
+removed a.go:3:1: // TODO removed
+added a.go:7:1: // TODO added
+`
+	if buf.String() != want {
+		fmt.Println("got", buf.String(), "want", want)
+		t.Fatalf("comments not compared as expected")
+	}
+}
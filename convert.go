@@ -0,0 +1,160 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Comment styles for ConvertStyle.
+const (
+	LineStyle  = "line"  // consecutive line comments, such as // or #
+	BlockStyle = "block" // block comments, such as /* */ or =begin =end
+)
+
+// ConvertStyle returns src, the contents of file, with its comments converted
+// to style, LineStyle or BlockStyle, and the number of comments converted.
+// The line and block delimiters of a comment are taken from the same entry of
+// Extensions, so comments without the other form are left alone.
+//
+// Converting to blocks joins consecutive line comments with the same
+// indentation that are alone on their lines; a trailing line comment becomes
+// an inline block comment. Converting to lines needs the block comment to end
+// its line. Indentation and the body text are preserved; shebang lines and
+// comments holding directives, see ToolDirectives, are left alone.
+func ConvertStyle(file string, src []byte, style string) ([]byte, int, error) {
+	if style != LineStyle && style != BlockStyle {
+		return nil, 0, fmt.Errorf("unknown comment style %q", style)
+	}
+	comments := ReadComments(file, bytes.NewReader(src), "")
+	var edits []Edit
+	converted := 0
+	for i := 0; i < len(comments); i++ {
+		c := comments[i]
+		syntax, block := commentSyntax(c)
+		if syntax.startSingle == "" || syntax.startMulti == "" || isShebang(c) || isToolDirective(c) {
+			continue
+		}
+		indent, alone, atLineEnd := commentPlace(src, c)
+		if !atLineEnd {
+			continue
+		}
+		switch {
+		case style == LineStyle && block:
+			if !alone && c.Pos.Line != c.End.Line {
+				continue
+			}
+			lines := commentBody(c)
+			for j, line := range lines {
+				lines[j] = strings.TrimRight(syntax.startSingle+" "+line, " ")
+			}
+			if len(lines) == 0 {
+				lines = []string{syntax.startSingle}
+			}
+			edits = append(edits, Edit{Offset: c.Pos.Offset, End: c.End.Offset, NewText: strings.Join(lines, "\n"+indent)})
+			converted++
+		case style == BlockStyle && !block:
+			// join the following line comments of the same kind
+			group := comments[i : i+1]
+			for alone && i+len(group) < len(comments) {
+				next := comments[i+len(group)]
+				s, b := commentSyntax(next)
+				nextIndent, nextAlone, nextEnd := commentPlace(src, next)
+				if b || s.startSingle != syntax.startSingle || s.startMulti != syntax.startMulti || next.Pos.Line != group[len(group)-1].Pos.Line+1 || nextIndent != indent || !nextAlone || !nextEnd || isToolDirective(next) {
+					break
+				}
+				group = comments[i : i+len(group)+1]
+			}
+			text, ok := blockComment(syntax, group, indent, alone)
+			if !ok {
+				continue
+			}
+			edits = append(edits, Edit{Offset: c.Pos.Offset, End: group[len(group)-1].End.Offset, NewText: text})
+			converted += len(group)
+			i += len(group) - 1
+		}
+	}
+	out, err := ApplyEdits(src, edits)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, converted, nil
+}
+
+// blockComment returns the line comments of group as a block comment and
+// whether they can be written as one.
+func blockComment(syntax CommentValues, group []CommentInfo, indent string, alone bool) (string, bool) {
+	var lines []string
+	for _, c := range group {
+		start, end := bodyBounds(c)
+		line := strings.TrimRight(strings.TrimPrefix(c.Text[start:end], " "), " \t\r")
+		if strings.Contains(line, syntax.endMulti) {
+			return "", false
+		}
+		lines = append(lines, line)
+	}
+	multiLine := syntax.startMulti == "=begin"
+	if multiLine && (indent != "" || !alone) {
+		// =begin and =end must start their lines
+		return "", false
+	}
+	if len(lines) == 1 && !multiLine {
+		return syntax.startMulti + " " + lines[0] + " " + syntax.endMulti, true
+	}
+	gutter, closing := "", syntax.endMulti
+	if syntax.startMulti == "/*" {
+		gutter, closing = " * ", " "+syntax.endMulti
+	}
+	var b strings.Builder
+	b.WriteString(syntax.startMulti)
+	for _, line := range lines {
+		b.WriteString("\n" + strings.TrimRight(indent+gutter+line, " "))
+	}
+	b.WriteString("\n" + indent + closing)
+	return b.String(), true
+}
+
+// commentSyntax returns the entry of Extensions whose delimiters start c and
+// whether c is a block comment.
+func commentSyntax(c CommentInfo) (CommentValues, bool) {
+	syntax := syntaxFor(filepath.Ext(c.Pos.Filename))
+	for _, v := range syntax {
+		if v.startMulti != "" && strings.HasPrefix(c.Text, v.startMulti) {
+			return v, true
+		}
+	}
+	for _, v := range syntax {
+		if v.startSingle != "" && strings.HasPrefix(c.Text, v.startSingle) {
+			if v.startMulti != "" {
+				return v, false
+			}
+			// prefer an entry having a block form for the same line comments
+			for _, w := range syntax {
+				if w.startSingle == v.startSingle && w.startMulti != "" {
+					return w, false
+				}
+			}
+			return v, false
+		}
+	}
+	return CommentValues{}, false
+}
+
+// commentPlace returns the white space preceding c on its first line, whether
+// nothing else precedes it and whether c ends its last line.
+func commentPlace(src []byte, c CommentInfo) (indent string, alone, atLineEnd bool) {
+	lineStart := bytes.LastIndexByte(src[:c.Pos.Offset], '\n') + 1
+	before := src[lineStart:c.Pos.Offset]
+	alone = len(bytes.TrimLeft(before, " \t")) == 0
+	if alone {
+		indent = string(before)
+	} else {
+		indent = string(before[:len(before)-len(bytes.TrimLeft(before, " \t"))])
+	}
+	rest := src[c.End.Offset:]
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+	return indent, alone, len(bytes.TrimSpace(rest)) == 0
+}
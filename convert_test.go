@@ -0,0 +1,50 @@
+package lexer_test
+
+import (
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestConvertStyle(t *testing.T) {
+	tests := []struct {
+		file, style, src, want string
+		n                      int
+	}{
+		{"a.go", lexer.BlockStyle,
+			"func f() {\n\t// one\n\t//   two\n\tx := 1 // three\n}\n",
+			"func f() {\n\t/*\n\t * one\n\t *   two\n\t */\n\tx := 1 /* three */\n}\n", 3},
+		{"a.go", lexer.LineStyle,
+			"func f() {\n\t/*\n\t * one\n\t *   two\n\t */\n\tx := 1 /* three */\n\ty := /* inline */ 2\n}\n",
+			"func f() {\n\t// one\n\t//   two\n\tx := 1 // three\n\ty := /* inline */ 2\n}\n", 2},
+		{"a.go", lexer.LineStyle,
+			"/* three\n   lines\n     indented */\n",
+			"// three\n// lines\n//   indented\n", 1},
+		{"a.rb", lexer.BlockStyle,
+			"#!/usr/bin/env ruby\n# one\n# two\ndef f\n  # indented\nend\n",
+			"#!/usr/bin/env ruby\n=begin\none\ntwo\n=end\ndef f\n  # indented\nend\n", 2},
+		{"a.rb", lexer.LineStyle,
+			"=begin\none\n  two\n=end\nx = 1\n",
+			"# one\n#   two\nx = 1\n", 1},
+		{"a.py", lexer.BlockStyle, "# no block comments\n", "# no block comments\n", 0},
+		{"a.go", lexer.BlockStyle, "// a */ b\n", "// a */ b\n", 0},
+		{"a.go", lexer.BlockStyle,
+			"//go:build linux\n\n// Package a.\n//nolint:all\npackage a\n",
+			"//go:build linux\n\n/* Package a. */\n//nolint:all\npackage a\n", 1},
+		{"a.go", lexer.BlockStyle,
+			"var u = \"http://example.com\" // c\nvar r = '/' // d\n",
+			"var u = \"http://example.com\" /* c */\nvar r = '/' /* d */\n", 2},
+	}
+	for _, test := range tests {
+		got, n, err := lexer.ConvertStyle(test.file, []byte(test.src), test.style)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want || n != test.n {
+			t.Errorf("%s to %s: got %q (%d), want %q (%d)", test.file, test.style, got, n, test.want, test.n)
+		}
+	}
+	if _, _, err := lexer.ConvertStyle("a.go", nil, "other"); err == nil {
+		t.Errorf("unknown style should be an error")
+	}
+}
@@ -0,0 +1,77 @@
+package lexer
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// copyrightYearsRe matches the years of a copyright statement.
+var copyrightYearsRe = regexp.MustCompile(`(?:Copyright|COPYRIGHT|\([cC]\)|©)(?:\s*(?:\([cC]\)|©))?[\s:]*(` + yearPattern + `)`)
+
+// lastYearRe matches the last year of the years of a copyright statement and
+// whether it ends a range.
+var lastYearRe = regexp.MustCompile(`(\s*[-–]\s*)?(\d{4}|present)$`)
+
+// UpdateCopyrightYears returns src, the contents of file, with the copyright
+// statements in its header comments extended to year: "2009" becomes
+// "2009-2024", "2019-2022" becomes "2019-2024" and "2018, 2020" becomes
+// "2018, 2020-2024". Statements already covering year, or ending in
+// "present", are left alone. It also reports whether src changed.
+func UpdateCopyrightYears(file string, src []byte, year int) ([]byte, bool, error) {
+	comments := ReadComments(file, bytes.NewReader(src), "")
+	from, to := headerComments(src, comments)
+	var edits []Edit
+	for _, c := range comments[from:to] {
+		for _, m := range copyrightYearsRe.FindAllStringSubmatchIndex(c.Text, -1) {
+			years := c.Text[m[2]:m[3]]
+			updated := extendYears(years, year)
+			if updated != years {
+				edits = append(edits, Edit{Offset: c.Pos.Offset + m[2], End: c.Pos.Offset + m[3], NewText: updated})
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return src, false, nil
+	}
+	out, err := ApplyEdits(src, edits)
+	return out, err == nil, err
+}
+
+// extendYears returns years extended to year, see UpdateCopyrightYears.
+func extendYears(years string, year int) string {
+	m := lastYearRe.FindStringSubmatchIndex(years)
+	if m == nil || years[m[4]:m[5]] == "present" {
+		return years
+	}
+	last, _ := strconv.Atoi(years[m[4]:m[5]])
+	if last >= year {
+		return years
+	}
+	if m[2] >= 0 {
+		// a range: move its end
+		return years[:m[4]] + strconv.Itoa(year)
+	}
+	return years + "-" + strconv.Itoa(year)
+}
+
+// LastCommitYear returns the year of the last commit changing file in the
+// git repository holding it, or 0 if file was never committed.
+func LastCommitYear(file string) (int, error) {
+	out, err := git(filepath.Dir(file), "log", "-1", "--format=%at", "--", filepath.Base(file))
+	if err != nil {
+		return 0, err
+	}
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return 0, nil
+	}
+	sec, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Unix(sec, 0).UTC().Year(), nil
+}
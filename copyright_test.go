@@ -0,0 +1,47 @@
+package lexer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestUpdateCopyrightYears(t *testing.T) {
+	tests := []struct{ file, src, want string }{
+		{"a.go", "// Copyright 2009 The Go Authors. All rights reserved.\n\npackage a\n", "// Copyright 2009-2024 The Go Authors. All rights reserved.\n\npackage a\n"},
+		{"a.sh", "#!/bin/sh\n# Copyright (c) 2019 – 2022 Acme\n# Copyright 2018, 2020 Jane Doe\n", "#!/bin/sh\n# Copyright (c) 2019 – 2024 Acme\n# Copyright 2018, 2020-2024 Jane Doe\n"},
+		{"a.c", "/* Copyright 2024 Acme */\n", "/* Copyright 2024 Acme */\n"},
+		{"a.c", "/* Copyright 2020-present Acme */\n", "/* Copyright 2020-present Acme */\n"},
+		{"a.go", "package a\n\n// Copyright 2009 not a header\n", "package a\n\n// Copyright 2009 not a header\n"},
+	}
+	for _, test := range tests {
+		got, changed, err := lexer.UpdateCopyrightYears(test.file, []byte(test.src), 2024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want || changed != (test.src != test.want) {
+			t.Errorf("%s: got %q (changed %v), want %q", test.file, got, changed, test.want)
+		}
+	}
+}
+
+func TestLastCommitYear(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.go": "package a\n"})
+	year, err := lexer.LastCommitYear(filepath.Join(dir, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if year != time.Now().UTC().Year() {
+		t.Fatalf("unexpected year %d", year)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "new.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if year, err := lexer.LastCommitYear(filepath.Join(dir, "new.go")); err != nil || year != 0 {
+		t.Fatalf("uncommitted file should have year 0, got %d %v", year, err)
+	}
+}
@@ -0,0 +1,319 @@
+package lexer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ChangedLines maps file names to the lines added or modified in them, as
+// found in a unified diff. Line numbers refer to the new version of a file.
+type ChangedLines map[string]map[int]bool
+
+// ParseDiff reads a unified diff, as written by diff -u or git diff, and
+// returns the lines it adds. File names have the a/ and b/ prefixes of git
+// removed. Deleted files are left out.
+func ParseDiff(r io.Reader) (ChangedLines, error) {
+	changed := make(ChangedLines)
+	var lines map[int]bool
+	var line, oldLeft, newLeft int
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		text := sc.Text()
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				if lines != nil {
+					lines[line] = true
+				}
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, "\\"):
+				// \ No newline at end of file
+			default:
+				line++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			name := diffFileName(text[4:])
+			lines = nil
+			if name != "/dev/null" {
+				lines = changed[name]
+				if lines == nil {
+					lines = make(map[int]bool)
+					changed[name] = lines
+				}
+			}
+		case strings.HasPrefix(text, "@@ "):
+			var err error
+			line, oldLeft, newLeft, err = parseHunkHeader(text)
+			if err != nil {
+				return nil, fmt.Errorf("diff line %d: %v", n, err)
+			}
+		}
+	}
+	return changed, sc.Err()
+}
+
+// diffFileName returns the file name from the value of a +++ line.
+func diffFileName(name string) string {
+	if i := strings.IndexByte(name, '\t'); i >= 0 {
+		// diff -u appends the modification time
+		name = name[:i]
+	}
+	if uq, err := strconv.Unquote(name); err == nil {
+		name = uq
+	}
+	if name == "/dev/null" {
+		return name
+	}
+	if strings.HasPrefix(name, "b/") {
+		name = name[2:]
+	}
+	return filepath.ToSlash(filepath.Clean(name))
+}
+
+// parseHunkHeader parses "@@ -l,s +l,s @@" and returns the first new line and
+// the number of old and new lines in the hunk.
+func parseHunkHeader(text string) (first, oldCount, newCount int, err error) {
+	fields := strings.Fields(text)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header %q", text)
+	}
+	_, oldCount, err = parseRange(fields[1][1:])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	first, newCount, err = parseRange(fields[2][1:])
+	return first, oldCount, newCount, err
+}
+
+// parseRange parses the "line,count" of a hunk header where the count
+// defaults to 1.
+func parseRange(s string) (line, count int, err error) {
+	count = 1
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		if count, err = strconv.Atoi(s[i+1:]); err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk range %q", s)
+		}
+		s = s[:i]
+	}
+	if line, err = strconv.Atoi(s); err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk range %q", s)
+	}
+	return line, count, nil
+}
+
+// GitDiff returns the lines changed in the working tree of the git
+// repository at dir compared to the base revision. File names are relative
+// to dir.
+func GitDiff(dir string, base string) (ChangedLines, error) {
+	return gitDiff(dir, base)
+}
+
+// StagedDiff returns the lines changed in the index of the git repository at
+// dir compared to HEAD, that is the changes that will be committed. File
+// names are relative to dir.
+func StagedDiff(dir string) (ChangedLines, error) {
+	return gitDiff(dir, "--cached")
+}
+
+// gitDiff runs git diff with args in dir and parses its output. The a/ and b/
+// prefixes are forced, whatever diff.mnemonicPrefix or diff.noprefix say.
+func gitDiff(dir string, args ...string) (ChangedLines, error) {
+	args = append([]string{"diff", "--no-color", "--no-ext-diff", "--relative", "-U0", "--src-prefix=a/", "--dst-prefix=b/"}, args...)
+	out, err := git(dir, append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDiff(bytes.NewReader(out))
+}
+
+// Filter returns the comments on at least one changed line. A block comment
+// spanning changed and unchanged lines is kept as a whole.
+func (c ChangedLines) Filter(comments []CommentInfo) []CommentInfo {
+	var kept []CommentInfo
+	for _, cm := range comments {
+		lines := c[filepath.ToSlash(filepath.Clean(cm.Pos.Filename))]
+		for l := cm.Pos.Line; l <= cm.End.Line; l++ {
+			if lines[l] {
+				kept = append(kept, cm)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// diffContext is the number of unchanged lines around the changes in a hunk
+// written by WriteUnifiedDiff.
+const diffContext = 3
+
+// WriteUnifiedDiff writes the changes from old to new as a unified diff of
+// file name, in the format of git diff so ParseDiff can read it. Nothing is
+// written if old and new are equal.
+func WriteUnifiedDiff(w io.Writer, name string, old, new []byte) error {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	ops := diffLines(splitLines(old), splitLines(new))
+	name = strings.TrimLeft(filepath.ToSlash(filepath.Clean(name)), "/")
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// extend the hunk while the next change is close enough to share context
+		start, end := i-diffContext, i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		if start < 0 {
+			start = 0
+		}
+		if end += diffContext; end > len(ops) {
+			end = len(ops)
+		}
+		writeHunk(&out, ops, start, end)
+		i = end
+	}
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// diffOp is a line of a diff: kept (' '), removed ('-') or added ('+').
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// writeHunk writes the hunk of ops[start:end].
+func writeHunk(out *bytes.Buffer, ops []diffOp, start, end int) {
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	// an empty range starts at the line before it
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	for _, op := range ops[start:end] {
+		out.WriteByte(op.kind)
+		out.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits src after each line break.
+func splitLines(src []byte) []string {
+	var lines []string
+	for len(src) > 0 {
+		i := bytes.IndexByte(src, '\n') + 1
+		if i == 0 {
+			i = len(src)
+		}
+		lines = append(lines, string(src[:i]))
+		src = src[i:]
+	}
+	return lines
+}
+
+// diffLines returns the operations turning the lines a into the lines b,
+// using the longest common subsequence of the lines that differ. Changes
+// too large to compare line by line are written as replacing every line.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		ops = append(ops, diffOp{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	var suffix []diffOp
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append([]diffOp{{' ', a[len(a)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	if len(a)*len(b) > 1<<22 {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return append(ops, suffix...)
+	}
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return append(ops, suffix...)
+}
@@ -0,0 +1,133 @@
+package lexer_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+const changedSrc = `package a
+
+// TODO old comment
+/* block comment
+ TODO with one new line
+*/
+// TODO new comment
+// -- not a header
+`
+
+const changedDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -4,2 +4,2 @@ package a
+ /* block comment
+- old line
++ TODO with one new line
+@@ -6,1 +7,2 @@
+--- removed line that looks like a header
++// TODO new comment
++// -- not a header
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-// TODO removed
+`
+
+func TestChangedLinesFilter(t *testing.T) {
+	changed, err := lexer.ParseDiff(strings.NewReader(changedDiff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := lexer.ReadComments("./a.go", strings.NewReader(changedSrc), "")
+	res := ""
+	for _, c := range changed.Filter(comments) {
+		res += fmt.Sprintf("%d-%d|", c.Pos.Line, c.End.Line)
+	}
+
+	want := "4-6|7-7|8-8|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("diff did not select the changed comments")
+	}
+	if len(changed) != 1 {
+		t.Fatalf("deleted files should not be in the changed lines: %v", changed)
+	}
+}
+
+func TestGitDiff(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.go": "package a\n\n// TODO old\n"})
+	gitRun(t, dir, "tag", "base")
+	gitCommit(t, dir, map[string]string{"a.go": "package a\n\n// TODO old\n// TODO new\n"})
+
+	changed, err := lexer.GitDiff(dir, "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments, err := lexer.ScanFile(filepath.Join(dir, "a.go"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range comments {
+		comments[i].Pos.Filename = "a.go"
+	}
+	if got := changed.Filter(comments); len(got) != 1 || got[0].Text != "// TODO new" {
+		t.Fatalf("unexpected changed comments %v", got)
+	}
+}
+
+func TestGitDiffPrefixConfig(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.go": "package a\n"})
+	gitRun(t, dir, "config", "diff.mnemonicPrefix", "true")
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\n// TODO staged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "a.go")
+
+	staged, err := lexer.StagedDiff(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !staged["a.go"][3] {
+		t.Fatalf("staged line not found in %v", staged)
+	}
+	gitRun(t, dir, "commit", "-q", "-m", "test")
+	changed, err := lexer.GitDiff(dir, "HEAD~1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed["a.go"][3] {
+		t.Fatalf("changed line not found in %v", changed)
+	}
+}
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16"
+	new := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n17\n"
+	var out strings.Builder
+	if err := lexer.WriteUnifiedDiff(&out, "dir/a.txt", []byte(old), []byte(new)); err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a/dir/a.txt\n+++ b/dir/a.txt\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -13,4 +13,5 @@\n 13\n 14\n 15\n-16\n\\ No newline at end of file\n+16\n+17\n"
+	if out.String() != want {
+		t.Fatalf("unexpected diff\n%s\nwant\n%s", out.String(), want)
+	}
+
+	changed, err := lexer.ParseDiff(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := changed["dir/a.txt"]; len(lines) != 3 || !lines[3] || !lines[16] || !lines[17] {
+		t.Fatalf("written diff not read back as expected: %v", changed)
+	}
+
+	out.Reset()
+	if lexer.WriteUnifiedDiff(&out, "a", []byte(old), []byte(old)); out.Len() != 0 {
+		t.Fatalf("diff written for equal contents: %q", out.String())
+	}
+}
@@ -0,0 +1,175 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// datePattern matches the dates understood by Due.
+var datePattern = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+
+// Due returns the first date written as YYYY-MM-DD in the comment, such as
+// in "TODO(2024-05-01) drop the old API" or "@todo due:2024-05-01".
+func (c CommentInfo) Due() (time.Time, bool) {
+	for _, m := range datePattern.FindAllString(c.Text, -1) {
+		if t, err := time.Parse("2006-01-02", m); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// summary returns the text of the comment without its comment characters and
+// with white space collapsed, suitable for a one line task description.
+func summary(c CommentInfo) string {
+	return strings.Join(strings.Fields(c.Body()), " ")
+}
+
+// taskTitle returns the summary of the comment without a leading tag, so
+// "// TODO(bob): fix it" becomes "fix it".
+func taskTitle(c CommentInfo) string {
+	text := summary(c)
+	for _, tag := range DefaultTags {
+		if tagIndex(text, tag) != 0 {
+			continue
+		}
+		rest := text[len(tag):]
+		if text[0] == '@' {
+			rest = text[len(tag)+1:]
+		}
+		if strings.HasPrefix(rest, "(") {
+			if i := strings.IndexByte(rest, ')'); i >= 0 {
+				rest = rest[i+1:]
+			}
+		}
+		if t := strings.TrimSpace(strings.TrimLeft(rest, ":-")); t != "" {
+			return t
+		}
+		break
+	}
+	return text
+}
+
+// project returns the top level directory of the file the comment is in, or
+// "" for files in the current directory.
+func project(c CommentInfo) string {
+	path := filepath.ToSlash(filepath.Clean(c.Pos.Filename))
+	if i := strings.IndexByte(path, '/'); i > 0 && !strings.HasPrefix(path, "../") {
+		return path[:i]
+	}
+	return ""
+}
+
+// writeTodoTxt writes one todo.txt task per comment. The top level directory
+// becomes the +project and the tags become @contexts, the creation date is
+// taken from the Blame field and the due date from Due.
+func writeTodoTxt(w io.Writer, comments []CommentInfo) error {
+	for _, c := range comments {
+		var parts []string
+		if c.Blame != nil {
+			parts = append(parts, c.Blame.Time.Format("2006-01-02"))
+		}
+		title := taskTitle(c)
+		due, hasDue := c.Due()
+		if hasDue {
+			// the due date is written once, as the key:value pair
+			title = strings.Join(strings.Fields(strings.Replace(" "+title+" ", " due:"+due.Format("2006-01-02")+" ", " ", 1)), " ")
+		}
+		parts = append(parts, title)
+		if p := project(c); p != "" {
+			parts = append(parts, "+"+todoTxtWord(p))
+		}
+		for _, tag := range c.Tags(nil) {
+			parts = append(parts, "@"+strings.ToLower(tag))
+		}
+		parts = append(parts, "file:"+todoTxtWord(filepath.ToSlash(c.Pos.Filename)), fmt.Sprintf("line:%d", c.Pos.Line))
+		if hasDue {
+			parts = append(parts, "due:"+due.Format("2006-01-02"))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(parts, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// todoTxtWord replaces the white space in s so it stays a single word.
+func todoTxtWord(s string) string {
+	return strings.Join(strings.Fields(s), "_")
+}
+
+// writeICal writes the comments as an iCalendar file with one VTODO per
+// comment. The due date is taken from Due.
+func writeICal(w io.Writer, comments []CommentInfo) error {
+	var b strings.Builder
+	line := func(s string) {
+		// lines longer than 75 octets are folded by starting the rest with a space
+		for len(s) > 75 {
+			cut := 75
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut--
+			}
+			b.WriteString(s[:cut] + "\r\n")
+			s = " " + s[cut:]
+		}
+		b.WriteString(s + "\r\n")
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Acetolyne//commentlex//EN")
+	for _, c := range comments {
+		line("BEGIN:VTODO")
+		line("UID:" + Fingerprint(c) + "@commentlex")
+		line("DTSTAMP:" + stamp)
+		if c.Blame != nil {
+			line("CREATED:" + c.Blame.Time.UTC().Format("20060102T150405Z"))
+		}
+		line("SUMMARY:" + icalText(taskTitle(c)))
+		line("DESCRIPTION:" + icalText(c.Pos.String()))
+		if tags := c.Tags(nil); len(tags) > 0 {
+			line("CATEGORIES:" + strings.Join(tags, ","))
+		}
+		if due, ok := c.Due(); ok {
+			line("DUE;VALUE=DATE:" + due.Format("20060102"))
+		}
+		line("STATUS:NEEDS-ACTION")
+		line("END:VTODO")
+	}
+	line("END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// icalText escapes s for use as an iCalendar TEXT value.
+func icalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeOrg writes one Org-mode TODO headline per comment with the tags as
+// Org tags, a DEADLINE from Due and a link to the comment in its file.
+func writeOrg(w io.Writer, comments []CommentInfo) error {
+	for _, c := range comments {
+		headline := "* TODO " + taskTitle(c)
+		if tags := c.Tags(nil); len(tags) > 0 {
+			headline += " :" + strings.Join(tags, ":") + ":"
+		}
+		if _, err := fmt.Fprintln(w, headline); err != nil {
+			return err
+		}
+		if due, ok := c.Due(); ok {
+			if _, err := fmt.Fprintf(w, "  DEADLINE: <%s>\n", due.Format("2006-01-02 Mon")); err != nil {
+				return err
+			}
+		}
+		file := filepath.ToSlash(c.Pos.Filename)
+		if _, err := fmt.Fprintf(w, "  [[file:%s::%d][%s:%d]]\n", file, c.Pos.Line, file, c.Pos.Line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package lexer_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+const exportSrc = "package pay\n\n// TODO(bob): drop the old API due:2024-05-01\n//@fixme rounding errors\n"
+
+func TestExportTodoTxt(t *testing.T) {
+	comments := lexer.ReadComments("pkg/pay.go", strings.NewReader(exportSrc), "")
+	var buf bytes.Buffer
+	if err := lexer.WriteReport(&buf, "todotxt", comments); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "drop the old API +pkg @todo file:pkg/pay.go line:3 due:2024-05-01\n" +
+		"rounding errors +pkg @fixme file:pkg/pay.go line:4\n"
+	if buf.String() != want {
+		fmt.Println("got", buf.String(), "want", want)
+		t.Fatalf("todo.txt export not as expected")
+	}
+}
+
+func TestExportICal(t *testing.T) {
+	comments := lexer.ReadComments("pkg/pay.go", strings.NewReader(exportSrc), "")
+	var buf bytes.Buffer
+	if err := lexer.WriteReport(&buf, "ical", comments); err != nil {
+		t.Fatal(err)
+	}
+	res := buf.String()
+
+	for _, want := range []string{"BEGIN:VCALENDAR\r\n", "SUMMARY:drop the old API due:2024-05-01\r\n", "DUE;VALUE=DATE:20240501\r\n", "CATEGORIES:FIXME\r\n", "END:VCALENDAR\r\n"} {
+		if !strings.Contains(res, want) {
+			fmt.Println("got", res, "want", want)
+			t.Fatalf("iCalendar export not as expected")
+		}
+	}
+	if strings.Count(res, "BEGIN:VTODO") != 2 {
+		t.Fatalf("want one VTODO per comment")
+	}
+}
+
+func TestExportOrg(t *testing.T) {
+	comments := lexer.ReadComments("pkg/pay.go", strings.NewReader(exportSrc), "")
+	var buf bytes.Buffer
+	if err := lexer.WriteReport(&buf, "org", comments); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "* TODO drop the old API due:2024-05-01 :TODO:\n  DEADLINE: <2024-05-01 Wed>\n  [[file:pkg/pay.go::3][pkg/pay.go:3]]\n" +
+		"* TODO rounding errors :FIXME:\n  [[file:pkg/pay.go::4][pkg/pay.go:4]]\n"
+	if buf.String() != want {
+		fmt.Println("got", buf.String(), "want", want)
+		t.Fatalf("Org-mode export not as expected")
+	}
+}
@@ -0,0 +1,174 @@
+package lexer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// treeEntry is a file in a git tree.
+type treeEntry struct {
+	path string // slash separated path from the root of the tree
+	blob string // object name of the file contents
+}
+
+// gitTree lists the files of revision rev in the repository at repo that have
+// a supported extension. Submodules and symbolic links are left out. If paths
+// are given only the files in them are listed.
+func gitTree(repo string, rev string, paths ...string) ([]treeEntry, error) {
+	args := append([]string{"ls-tree", "-r", "-z", "--full-tree", rev, "--"}, paths...)
+	out, err := git(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	var entries []treeEntry
+	for _, rec := range strings.Split(string(out), "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		tab := strings.IndexByte(rec, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(rec[:tab])
+		if len(fields) != 3 || fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+		if path := rec[tab+1:]; Supported(path) {
+			entries = append(entries, treeEntry{path: path, blob: fields[2]})
+		}
+	}
+	return entries, nil
+}
+
+// gitBlobs reads the contents of blobs from the repository at repo with a
+// single git cat-file process and calls fn with the contents of each blob, in
+// the order of blobs.
+func gitBlobs(repo string, blobs []string, fn func(i int, data []byte) error) error {
+	if len(blobs) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader(strings.Join(blobs, "\n") + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	err = readBatch(bufio.NewReader(stdout), len(blobs), fn)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git cat-file: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// readBatch reads n objects in the output format of git cat-file --batch.
+func readBatch(r *bufio.Reader, n int, fn func(i int, data []byte) error) error {
+	for i := 0; i < n; i++ {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("git cat-file: %v", err)
+		}
+		// <object> SP <type> SP <size> LF <contents> LF
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return fmt.Errorf("git cat-file: %s", strings.TrimSpace(header))
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return fmt.Errorf("git cat-file: invalid header %q", header)
+		}
+		data := make([]byte, size+1)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("git cat-file: %v", err)
+		}
+		if err := fn(i, data[:size]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkRevision calls fn with the path and contents of every file with a
+// supported extension in revision rev of the git repository at repo. The
+// blobs are read from the object database, so repo may be a bare repository
+// and nothing is checked out. If paths are given only the files in them are
+// walked. Paths are slash separated and relative to the root of the tree.
+func WalkRevision(repo string, rev string, fn func(path string, src []byte) error, paths ...string) error {
+	entries, err := gitTree(repo, rev, paths...)
+	if err != nil {
+		return err
+	}
+	blobs := make([]string, len(entries))
+	for i, e := range entries {
+		blobs[i] = e.blob
+	}
+	return gitBlobs(repo, blobs, func(i int, data []byte) error {
+		return fn(entries[i].path, data)
+	})
+}
+
+// ScanRevision returns the comments in the files of revision rev of the git
+// repository at repo without checking it out, see WalkRevision. The file
+// names of the comments are the paths in the tree.
+func ScanRevision(repo string, rev string, match string, paths ...string) ([]CommentInfo, error) {
+	return scanTree(repo, rev, match, nil, paths...)
+}
+
+// scanTree scans the files of revision rev like ScanRevision. If cache is not
+// nil it holds the comments of blobs scanned before, keyed by the blob and
+// the extension of the file, and newly scanned blobs are added to it.
+func scanTree(repo string, rev string, match string, cache map[string][]CommentInfo, paths ...string) ([]CommentInfo, error) {
+	entries, err := gitTree(repo, rev, paths...)
+	if err != nil {
+		return nil, err
+	}
+	// comments per entry, so they are returned in the order of the tree
+	found := make([][]CommentInfo, len(entries))
+	var missing []int
+	for i, e := range entries {
+		cached, ok := cache[e.blob+path.Ext(e.path)]
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+		for _, c := range cached {
+			c.Pos.Filename, c.End.Filename = e.path, e.path
+			found[i] = append(found[i], c)
+		}
+	}
+
+	blobs := make([]string, len(missing))
+	for i, m := range missing {
+		blobs[i] = entries[m].blob
+	}
+	err = gitBlobs(repo, blobs, func(i int, data []byte) error {
+		e := entries[missing[i]]
+		found[missing[i]] = ReadComments(e.path, bytes.NewReader(data), match)
+		if cache != nil {
+			cache[e.blob+path.Ext(e.path)] = found[missing[i]]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var comments []CommentInfo
+	for _, f := range found {
+		comments = append(comments, f...)
+	}
+	return comments, nil
+}
@@ -0,0 +1,58 @@
+package lexer_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestScanRevision(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.go": "// TODO first\n", "pkg/b.sh": "# TODO shell\n", "notes.txt": "// not scanned\n"})
+	gitRun(t, dir, "tag", "v1")
+	gitCommit(t, dir, map[string]string{"a.go": "// TODO second\n"})
+
+	comments, err := lexer.ScanRevision(dir, "v1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ""
+	for _, c := range comments {
+		res += fmt.Sprintf("%s %s|", c.Pos, c.Text)
+	}
+
+	want := "a.go:1:1 // TODO first|pkg/b.sh:1:1 # TODO shell|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("revision not scanned as expected")
+	}
+}
+
+func TestScanRevisionUnknown(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.go": "// TODO first\n"})
+	if _, err := lexer.ScanRevision(dir, "no-such-revision", ""); err == nil {
+		t.Fatalf("scanning an unknown revision should fail")
+	}
+}
+
+func TestWalkBareRepository(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.go": "// TODO root\n", "pkg/b.go": "// TODO pkg\n", "pkg/c.txt": "unsupported\n"})
+	bare := filepath.Join(t.TempDir(), "mirror.git")
+	gitRun(t, dir, "clone", "-q", "--bare", dir, bare)
+
+	res := ""
+	err := lexer.WalkRevision(bare, "HEAD", func(path string, src []byte) error {
+		res += path + "=" + string(src)
+		return nil
+	}, "pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "pkg/b.go=// TODO pkg\n"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("bare repository not walked as expected")
+	}
+}
@@ -0,0 +1,144 @@
+package lexer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryOptions selects the commits History scans and the comments counted.
+type HistoryOptions struct {
+	Every    int           // scan every Nth commit, 0 or 1 scans all of them
+	Interval time.Duration // scan at most one commit per interval, 0 disables it
+	Match    string        // only count comments matching Match, see Scanner.Match
+	Tags     []string      // tags to count, DefaultTags if nil
+}
+
+// HistoryPoint holds the comment counts of one commit.
+type HistoryPoint struct {
+	Commit    string
+	Time      time.Time
+	Total     int
+	Tags      map[string]int // comments per tag
+	Languages map[string]int // comments per file extension
+	Dirs      map[string]int // comments per top level directory
+}
+
+// History walks the first parent history of rev in the git repository at repo
+// from the oldest commit to rev and counts the comments of the commits chosen
+// by opts. The newest commit is always included. Blobs unchanged between
+// commits are only scanned once.
+func History(repo string, rev string, opts HistoryOptions) ([]HistoryPoint, error) {
+	out, err := git(repo, "log", "--first-parent", "--reverse", "--format=%H %ct", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	type commit struct {
+		id   string
+		time time.Time
+	}
+	var commits []commit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		sec, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("git log: invalid commit time %q", fields[1])
+		}
+		commits = append(commits, commit{fields[0], time.Unix(sec, 0).UTC()})
+	}
+
+	var points []HistoryPoint
+	var last time.Time
+	cache := make(map[string][]CommentInfo)
+	for i, c := range commits {
+		newest := i == len(commits)-1
+		if !newest {
+			if opts.Every > 1 && i%opts.Every != 0 {
+				continue
+			}
+			if opts.Interval > 0 && len(points) > 0 && c.time.Sub(last) < opts.Interval {
+				continue
+			}
+		}
+		comments, err := scanTree(repo, c.id, opts.Match, cache)
+		if err != nil {
+			return nil, err
+		}
+		last = c.time
+		points = append(points, countComments(c.id, c.time, comments, opts.Tags))
+	}
+	return points, nil
+}
+
+// countComments returns the counts of comments for a HistoryPoint.
+func countComments(commit string, t time.Time, comments []CommentInfo, tags []string) HistoryPoint {
+	p := HistoryPoint{
+		Commit:    commit,
+		Time:      t,
+		Total:     len(comments),
+		Tags:      make(map[string]int),
+		Languages: make(map[string]int),
+		Dirs:      make(map[string]int),
+	}
+	for _, c := range comments {
+		for _, tag := range c.Tags(tags) {
+			p.Tags[tag]++
+		}
+		p.Languages[filepath.Ext(c.Pos.Filename)]++
+		dir := project(c)
+		if dir == "" {
+			dir = "."
+		}
+		p.Dirs[dir]++
+	}
+	return p
+}
+
+// WriteHistory writes the result of History to w as csv or json. The csv
+// format has one row per commit and counted value with the columns commit,
+// time, kind (total, tag, language or dir), name and count.
+func WriteHistory(w io.Writer, format string, points []HistoryPoint) error {
+	switch format {
+	case "", "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"commit", "time", "kind", "name", "count"})
+		for _, p := range points {
+			row := func(kind, name string, n int) {
+				cw.Write([]string{p.Commit, p.Time.Format(time.RFC3339), kind, name, strconv.Itoa(n)})
+			}
+			row("total", "", p.Total)
+			for _, kind := range []struct {
+				name   string
+				counts map[string]int
+			}{{"tag", p.Tags}, {"language", p.Languages}, {"dir", p.Dirs}} {
+				names := make([]string, 0, len(kind.counts))
+				for name := range kind.counts {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					row(kind.name, name, kind.counts[name])
+				}
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		if points == nil {
+			points = []HistoryPoint{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(points)
+	}
+	return fmt.Errorf("unknown history format %q", format)
+}
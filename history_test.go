@@ -0,0 +1,40 @@
+package lexer_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestHistory(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.go": "// TODO one\n", "pkg/b.sh": "# FIXME two\n"})
+	gitCommit(t, dir, map[string]string{"a.go": "// TODO one\n// TODO three\n"})
+	gitCommit(t, dir, map[string]string{"c.go": "// TODO four\n"})
+
+	points, err := lexer.History(dir, "HEAD", lexer.HistoryOptions{Every: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ""
+	for _, p := range points {
+		res += fmt.Sprintf("%d %d %d %d %d|", p.Total, p.Tags["TODO"], p.Tags["FIXME"], p.Languages[".go"], p.Dirs["pkg"])
+	}
+
+	// the first and the newest commit, skipping the second
+	want := "2 1 1 1 1|4 3 1 3 1|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("history counts not as expected")
+	}
+
+	var buf bytes.Buffer
+	if err := lexer.WriteHistory(&buf, "csv", points[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 8 || !strings.HasSuffix(lines[1], ",total,,2") {
+		t.Fatalf("unexpected csv output\n%s", buf.String())
+	}
+}
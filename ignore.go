@@ -0,0 +1,88 @@
+package lexer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Ignore decides which paths of a directory tree are skipped, using the
+// patterns of the .gitignore file at its root and any extra patterns.
+// Patterns follow the gitignore syntax: a trailing / only matches
+// directories, a leading ! includes a path again and the last matching
+// pattern wins. The .git directory is always ignored.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// LoadIgnore returns the ignore rules of the tree at root: the patterns of
+// root/.gitignore, if it exists, followed by patterns.
+func LoadIgnore(root string, patterns ...string) (*Ignore, error) {
+	var all []string
+	f, err := os.Open(filepath.Join(root, ".gitignore"))
+	if err == nil {
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			all = append(all, sc.Text())
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return NewIgnore(append(all, patterns...)...)
+}
+
+// NewIgnore returns the ignore rules for patterns. Empty patterns and
+// patterns starting with # are skipped.
+func NewIgnore(patterns ...string) (*Ignore, error) {
+	ig := &Ignore{}
+	for _, p := range append([]string{".git/"}, patterns...) {
+		p = strings.TrimRight(p, " \r")
+		if p == "" || p[0] == '#' {
+			continue
+		}
+		var r ignoreRule
+		if p[0] == '!' {
+			r.negate = true
+			p = p[1:]
+		}
+		p = strings.TrimPrefix(p, "\\")
+		r.dirOnly = strings.HasSuffix(p, "/")
+		re, err := ownerPattern(p)
+		if err != nil {
+			return nil, err
+		}
+		r.re = re
+		ig.rules = append(ig.rules, r)
+	}
+	return ig, nil
+}
+
+// Ignored reports whether path, which is relative to the root of the tree,
+// is ignored. Set dir if path is a directory.
+func (ig *Ignore) Ignored(path string, dir bool) bool {
+	if ig == nil {
+		return false
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	ignored := false
+	for _, r := range ig.rules {
+		// directory patterns match everything below the directory, so the
+		// directory itself is matched as its own contents
+		if r.re.MatchString(path) || dir && r.dirOnly && r.re.MatchString(path+"/") {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
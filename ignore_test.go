@@ -0,0 +1,28 @@
+package lexer_test
+
+import (
+	"fmt"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestIgnorePatterns(t *testing.T) {
+	ig, err := lexer.NewIgnore("# build output", "build/", "*.log", "/vendor", "!keep.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ""
+	for _, p := range []struct {
+		path string
+		dir  bool
+	}{{"build", true}, {"build", false}, {"src/build/a.go", false}, {"x.log", false}, {"keep.log", false}, {"vendor/a.go", false}, {"src/vendor", true}, {".git", true}, {"main.go", false}} {
+		res += fmt.Sprintf("%s=%v ", p.path, ig.Ignored(p.path, p.dir))
+	}
+
+	want := "build=true build=false src/build/a.go=true x.log=true keep.log=false vendor/a.go=true src/vendor=false .git=true main.go=false "
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("ignore patterns not matched as expected")
+	}
+}
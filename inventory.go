@@ -0,0 +1,240 @@
+package lexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Copyright is a normalized copyright statement.
+type Copyright struct {
+	Years  string `json:",omitempty"` // such as "2019-2024" or "2020, 2022"
+	Holder string
+}
+
+func (c Copyright) String() string {
+	if c.Years == "" {
+		return "Copyright " + c.Holder
+	}
+	return "Copyright " + c.Years + " " + c.Holder
+}
+
+// FileLicenses holds the licences and copyright statements found in the
+// comments of a file.
+type FileLicenses struct {
+	File       string
+	Licenses   []string    `json:",omitempty"` // SPDX licence expressions
+	Copyrights []Copyright `json:",omitempty"`
+}
+
+// LicenseSummary lists the files under a licence and their copyright holders.
+type LicenseSummary struct {
+	License string
+	Files   []string
+	Holders []string
+}
+
+// Inventory holds the licences and copyright holders mentioned in comments,
+// per file and per licence.
+type Inventory struct {
+	Files    []FileLicenses
+	Licenses []LicenseSummary
+}
+
+// NoAssertion is the licence of files with copyright statements but without
+// an SPDX-License-Identifier, following the SPDX convention.
+const NoAssertion = "NOASSERTION"
+
+var (
+	spdxRe      = regexp.MustCompile(`SPDX-License-Identifier:\s*(.+)`)
+	copyrightRe = regexp.MustCompile(`^(?:Copyright|COPYRIGHT|\([cC]\)|©)(?:\s*(?:\([cC]\)|©))?[\s:]*(?:(` + yearPattern + `),?)?\s*(.*)$`)
+	reservedRe  = regexp.MustCompile(`(?i)[\s.,;]*all rights reserved\.?$`)
+)
+
+// NewInventory returns the SPDX-License-Identifier tags and copyright
+// statements found in comments. Licence expressions are normalized to single
+// spaces and upper case operators, copyright years to ranges written with a
+// hyphen and holders without "All rights reserved" and trailing punctuation.
+// Per licence the identifiers of an expression such as "Apache-2.0 OR MIT" are
+// counted separately.
+func NewInventory(comments []CommentInfo) *Inventory {
+	inv := &Inventory{}
+	index := make(map[string]int)
+	for _, c := range comments {
+		for _, line := range bodyLines(c) {
+			line = strings.TrimSpace(line)
+			license, copyright := parseSPDX(line), parseCopyright(line)
+			if license == "" && copyright == nil {
+				continue
+			}
+			i, ok := index[c.Pos.Filename]
+			if !ok {
+				i = len(inv.Files)
+				index[c.Pos.Filename] = i
+				inv.Files = append(inv.Files, FileLicenses{File: c.Pos.Filename})
+			}
+			f := &inv.Files[i]
+			if license != "" && !containsString(f.Licenses, license) {
+				f.Licenses = append(f.Licenses, license)
+			}
+			if copyright != nil && !containsCopyright(f.Copyrights, *copyright) {
+				f.Copyrights = append(f.Copyrights, *copyright)
+			}
+		}
+	}
+
+	files := make(map[string][]string)
+	holders := make(map[string][]string)
+	for _, f := range inv.Files {
+		ids := []string{}
+		for _, l := range f.Licenses {
+			for _, id := range licenseIDs(l) {
+				if !containsString(ids, id) {
+					ids = append(ids, id)
+				}
+			}
+		}
+		if len(ids) == 0 {
+			ids = append(ids, NoAssertion)
+		}
+		for _, id := range ids {
+			files[id] = append(files[id], f.File)
+			for _, c := range f.Copyrights {
+				if !containsString(holders[id], c.Holder) {
+					holders[id] = append(holders[id], c.Holder)
+				}
+			}
+		}
+	}
+	var ids []string
+	for id := range files {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		sort.Strings(holders[id])
+		inv.Licenses = append(inv.Licenses, LicenseSummary{License: id, Files: files[id], Holders: holders[id]})
+	}
+	return inv
+}
+
+// parseSPDX returns the normalized licence expression of an
+// SPDX-License-Identifier tag in line, or "".
+func parseSPDX(line string) string {
+	m := spdxRe.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	expr := m[1]
+	for _, end := range []string{"*/}}", "*/", "-->", "--]]"} {
+		expr = strings.TrimSuffix(strings.TrimSpace(expr), end)
+	}
+	words := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr))
+	for i, w := range words {
+		switch strings.ToUpper(w) {
+		case "AND", "OR", "WITH":
+			words[i] = strings.ToUpper(w)
+		}
+	}
+	expr = strings.Join(words, " ")
+	return strings.NewReplacer("( ", "(", " )", ")").Replace(expr)
+}
+
+// licenseIDs returns the licence identifiers of an SPDX expression. Licence
+// exceptions following WITH are kept with their licence.
+func licenseIDs(expr string) []string {
+	var ids []string
+	words := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(expr))
+	for i := 0; i < len(words); i++ {
+		switch words[i] {
+		case "AND", "OR":
+		case "WITH":
+			if len(ids) > 0 && i+1 < len(words) {
+				ids[len(ids)-1] += " WITH " + words[i+1]
+				i++
+			}
+		default:
+			ids = append(ids, words[i])
+		}
+	}
+	return ids
+}
+
+// parseCopyright returns the normalized copyright statement starting line,
+// or nil.
+func parseCopyright(line string) *Copyright {
+	m := copyrightRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	holder := reservedRe.ReplaceAllString(strings.Join(strings.Fields(m[2]), " "), "")
+	holder = strings.TrimRight(strings.TrimPrefix(holder, "by "), " .,;:")
+	if holder == "" || m[1] == "" && (!strings.HasPrefix(line, "Copyright") || strings.ToLower(holder[:1]) == holder[:1]) {
+		// without a year only "Copyright Holder" is a statement, unlike
+		// "Copyright notice" or "(c) see below"
+		return nil
+	}
+	years := strings.Join(strings.Fields(strings.ReplaceAll(m[1], "–", "-")), " ")
+	years = strings.NewReplacer(" - ", "-", "- ", "-", " -", "-", " ,", ",").Replace(years)
+	return &Copyright{Years: years, Holder: holder}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCopyright(list []Copyright, c Copyright) bool {
+	for _, v := range list {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteInventory writes inv to w in the given format. The text format lists
+// the licences and copyright statements of each file followed by the files
+// and holders of each licence, the json format writes inv as an object.
+func WriteInventory(w io.Writer, format string, inv *Inventory) error {
+	switch format {
+	case "", "text":
+		var b strings.Builder
+		for _, f := range inv.Files {
+			b.WriteString(f.File + "\n")
+			for _, l := range f.Licenses {
+				b.WriteString("\tSPDX-License-Identifier: " + l + "\n")
+			}
+			for _, c := range f.Copyrights {
+				b.WriteString("\t" + c.String() + "\n")
+			}
+		}
+		for _, l := range inv.Licenses {
+			fmt.Fprintf(&b, "%s (%d files)\n", l.License, len(l.Files))
+			for _, h := range l.Holders {
+				b.WriteString("\t" + h + "\n")
+			}
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	case "json":
+		out := *inv
+		if out.Files == nil {
+			out.Files = []FileLicenses{}
+		}
+		if out.Licenses == nil {
+			out.Licenses = []LicenseSummary{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
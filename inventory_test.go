@@ -0,0 +1,43 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestNewInventory(t *testing.T) {
+	var comments []lexer.CommentInfo
+	comments = append(comments, lexer.ReadComments("a.go", strings.NewReader("// Copyright (c) 2019 – 2024 Acme Inc. All rights reserved.\n// SPDX-License-Identifier: apache-2.0 or MIT\n\n// Copyright notice below.\npackage a\n"), "")...)
+	comments = append(comments, lexer.ReadComments("b.c", strings.NewReader("/*\n * © 2020, 2022 Jane Doe <jane@example.com>.\n * SPDX-License-Identifier: GPL-2.0-only WITH Classpath-exception-2.0 */\n"), "")...)
+	comments = append(comments, lexer.ReadComments("c.sh", strings.NewReader("# Copyright The Authors\n# Copyright 2021 Acme Inc\n"), "")...)
+	comments = append(comments, lexer.ReadComments("d.py", strings.NewReader("# nothing to see\n"), "")...)
+
+	var out strings.Builder
+	if err := lexer.WriteInventory(&out, "text", lexer.NewInventory(comments)); err != nil {
+		t.Fatal(err)
+	}
+	want := `a.go
+	SPDX-License-Identifier: apache-2.0 OR MIT
+	Copyright 2019-2024 Acme Inc
+b.c
+	SPDX-License-Identifier: GPL-2.0-only WITH Classpath-exception-2.0
+	Copyright 2020, 2022 Jane Doe <jane@example.com>
+c.sh
+	Copyright The Authors
+	Copyright 2021 Acme Inc
+GPL-2.0-only WITH Classpath-exception-2.0 (1 files)
+	Jane Doe <jane@example.com>
+MIT (1 files)
+	Acme Inc
+NOASSERTION (1 files)
+	Acme Inc
+	The Authors
+apache-2.0 (1 files)
+	Acme Inc
+`
+	if out.String() != want {
+		t.Fatalf("unexpected inventory\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -103,6 +103,28 @@ var Extensions = []CommentValues{
 	},
 }
 
+// Quotes lists the characters starting string and character literals per file
+// extension. Comment characters inside a literal do not start a comment. A
+// literal ends with its quote on the same line, a backslash escaping the
+// character after it, or at the end of the line when it is not terminated.
+// Backquoted literals have no escapes and may span lines, like Go raw strings
+// and JavaScript template literals.
+var Quotes = map[string]string{
+	".go":   "\"'`",
+	".js":   "\"'`",
+	".py":   "\"'",
+	".rs":   "\"", // ' also starts lifetimes
+	".php":  "\"'",
+	".c":    "\"'",
+	".cpp":  "\"'",
+	".h":    "\"'",
+	".java": "\"'",
+	".jsp":  "\"'",
+	".sh":   "\"'",
+	".lua":  "\"'",
+	".rb":   "\"'",
+}
+
 // IsValid reports whether the position is valid.
 func (pos *Position) IsValid() bool { return pos.Line > 0 }
 
@@ -141,6 +163,7 @@ const (
 	//ScanStrings    = 1 << -String
 	//ScanRawStrings = 1 << -RawString
 	ScanComments = 1 << -Comment
+	ScanCode     = 1 << -Code // Scan returns Code tokens for the source between comments as well
 	//SkipComments   = 1 << -skipComment // if set with ScanComments, comments become white space
 	GoTokens = ScanComments
 )
@@ -155,6 +178,7 @@ const (
 	//String
 	//RawString
 	Comment
+	Code
 
 	// internal use only
 	//skipComment
@@ -169,6 +193,7 @@ var tokenString = map[rune]string{
 	//String:    "String",
 	//RawString: "RawString",
 	Comment: "Comment",
+	Code:    "Code",
 }
 
 // TokenString returns a printable string for a token or Unicode character.
@@ -229,6 +254,11 @@ type Scanner struct {
 	tokPos int          // token text tail position (srcBuf index); valid if >= 0
 	tokEnd int          // token text tail end (srcBuf index)
 
+	// Segments returned in ScanCode mode, read on the first call to Scan
+	segments []segment
+	segNum   int      // number of segments returned
+	segEnd   Position // position after the last returned segment
+
 	// One character look-ahead
 	ch rune // character before current srcPos
 
@@ -271,28 +301,30 @@ type Scanner struct {
 // Error is set to nil, ErrorCount is set to 0, Mode is set to GoTokens,
 // and Whitespace is set to GoWhitespace.
 func (s *Scanner) Init(file string) *Scanner {
-
-	// All comment types that are possible when we first start scanning
-	s.singlePossible = true
-	s.multiPossible = true
-	if s.CommentStatusSingle == nil {
-		s.CommentStatusSingle = make(map[int]string)
-	}
-	if s.CommentStatusMulti == nil {
-		s.CommentStatusMulti = make(map[int]string)
-	}
-	if s.CommentStatusMultiEnd == nil {
-		s.CommentStatusMultiEnd = make(map[int]string)
-	}
-	if s.CommentStatusMultiAll == nil {
-		s.CommentStatusMultiAll = make(map[int]string)
-	}
-
 	// Get the filetype so we can set the comment characters for this scan
 	src, err := os.Open(file)
 	if err != nil {
 		panic(err)
 	}
+	return s.InitReader(file, src)
+}
+
+// InitReader initializes a Scanner to read src as the contents of file and
+// returns s. The file name is only used to choose the comment characters,
+// which makes it possible to scan sources that are not on disk.
+func (s *Scanner) InitReader(file string, src io.Reader) *Scanner {
+
+	// All comment types that are possible when we first start scanning
+	s.singlePossible = true
+	s.multiPossible = true
+	// start with empty comment status so a Scanner can be reused for another source
+	s.CommentStatusSingle = make(map[int]string)
+	s.CommentStatusMulti = make(map[int]string)
+	s.CommentStatusMultiEnd = make(map[int]string)
+	s.CommentStatusMultiAll = make(map[int]string)
+	s.ExtNum = 0
+	s.segments, s.segNum = nil, 0
+
 	s.src = src
 
 	s.srcType = filepath.Ext(file)
@@ -468,9 +500,49 @@ func (s *Scanner) error(msg string) {
 func (s *Scanner) scanComment(ch rune) rune {
 	isSingle := false
 	isMulti := false
+	quotes := Quotes[s.srcType]
+	var openers []string
+	longest := 0
+	if quotes != "" {
+		for _, v := range syntaxFor(s.srcType) {
+			for _, open := range []string{v.startSingle, v.startMulti} {
+				if open != "" {
+					openers = append(openers, open)
+					if len(open) > longest {
+						longest = len(open)
+					}
+				}
+			}
+		}
+	}
+	tail := ""      // end of the line outside literals, as long as the longest opener
+	opened := false // comment characters were found on the line
 
 	for ch >= 0 {
 		for {
+			if !opened && strings.ContainsRune(quotes, ch) {
+				ch = s.skipLiteral(ch)
+				// characters before the literal do not start comment characters after it
+				for v := range Extensions {
+					s.CommentStatusSingle[v] = ""
+					s.CommentStatusMulti[v] = ""
+					s.CommentStatusMultiAll[v] = ""
+				}
+				tail = ""
+				if ch != '\n' && ch != EOF {
+					ch = s.next()
+					continue
+				}
+			} else if quotes != "" && !opened {
+				if tail += string(ch); len(tail) > longest {
+					tail = tail[len(tail)-longest:]
+				}
+				for _, open := range openers {
+					if strings.HasSuffix(tail, open) {
+						opened = true
+					}
+				}
+			}
 			for v := range Extensions {
 				SingleFull := Extensions[v].startSingle
 				curext := Extensions[v].ext
@@ -495,6 +567,10 @@ func (s *Scanner) scanComment(ch rune) rune {
 							}
 						}
 						if Extensions[v].startMulti != "" {
+							if s.CommentStatusMulti[v] == "" {
+								// text of an earlier comment must not end this one
+								s.CommentStatusMultiAll[v] = ""
+							}
 							s.CommentStatusMultiAll[v] += string(ch)
 							if len(s.CommentStatusMulti[v]) < len(Extensions[v].startMulti) {
 								if string(ch) == string(Extensions[v].startMulti[len(s.CommentStatusMulti[v])]) {
@@ -537,6 +613,7 @@ func (s *Scanner) scanComment(ch rune) rune {
 								}
 							} else {
 								s.CommentStatusMultiEnd[v] = ""
+								return Comment
 							}
 						}
 						for !MultiEnded {
@@ -562,6 +639,14 @@ func (s *Scanner) scanComment(ch rune) rune {
 								}
 							}
 							ch = s.next()
+							if ch == EOF && len(s.CommentStatusMultiEnd[v]) < len(Extensions[v].endMulti) {
+								// the comment is not terminated and runs to the end of the source
+								s.CommentStatusMultiEnd[v] = ""
+								if s.Match == "" || strings.Contains(s.CommentStatusMultiAll[v], s.Match) {
+									return Comment
+								}
+								return EOF
+							}
 						}
 					}
 				}
@@ -579,6 +664,26 @@ func (s *Scanner) scanComment(ch rune) rune {
 	return ch
 }
 
+// skipLiteral reads the rest of the literal started by quote, see Quotes. It
+// returns the closing quote, or the line break or EOF ending a literal that is
+// not terminated.
+func (s *Scanner) skipLiteral(quote rune) rune {
+	for {
+		ch := s.next()
+		switch {
+		case ch == quote || ch == EOF:
+			return ch
+		case quote == '`':
+		case ch == '\n':
+			return ch
+		case ch == '\\':
+			if ch = s.next(); ch == '\n' || ch == EOF {
+				return ch
+			}
+		}
+	}
+}
+
 // Scan reads the next token or Unicode character from source and returns it.
 // It only recognizes tokens t for which the respective Mode bit (1<<-t) is set.
 // It returns EOF at the end of the source. It reports scanner errors (read and
@@ -586,6 +691,9 @@ func (s *Scanner) scanComment(ch rune) rune {
 // message to os.Stderr.
 
 func (s *Scanner) Scan() rune {
+	if s.Mode&ScanCode != 0 {
+		return s.scanSegment()
+	}
 	//go to the first character
 	ch := s.next()
 
@@ -642,6 +750,9 @@ func (s *Scanner) Scan() rune {
 // Use the Scanner's Position field for the start position of the most
 // recently scanned token.
 func (s *Scanner) Pos() (pos Position) {
+	if s.segNum > 0 {
+		return s.segEnd
+	}
 	pos.Filename = s.Filename
 	pos.Offset = s.srcBufOffset + s.srcPos - s.lastCharLen
 	switch {
@@ -664,6 +775,12 @@ func (s *Scanner) Pos() (pos Position) {
 // TokenText returns the string corresponding to the most recently scanned token.
 // Valid after calling Scan and in calls of Scanner.Error.
 func (s *Scanner) TokenText() string {
+	if s.segNum > 0 {
+		if s.segNum > len(s.segments) {
+			return ""
+		}
+		return s.segments[s.segNum-1].text
+	}
 	if s.tokPos < 0 {
 		// no token text
 		return ""
@@ -163,3 +163,125 @@ func TestPythonNewStyleCommentsWork(t *testing.T) {
 		t.Fatalf("unable to use ruby comments")
 	}
 }
+
+func TestInitReaderResetsCommentState(t *testing.T) {
+	res := ""
+	var s lexer.Scanner
+	s.Mode = lexer.ScanComments
+	for _, src := range []string{"# a // b\necho 1;\n", "echo 2;\n# c\n"} {
+		// stop after the first comment of each source
+		s.InitReader("test.php", strings.NewReader(src))
+		for tok := s.Scan(); tok != lexer.EOF; tok = s.Scan() {
+			if tok == lexer.Comment {
+				res += s.TokenText() + "|"
+				break
+			}
+		}
+	}
+
+	want := "# a // b|# c|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("comment state of the previous source kept by InitReader")
+	}
+}
+
+func TestSingleLineMultiCommentWithoutMatch(t *testing.T) {
+	res := ""
+	var s lexer.Scanner
+	s.Mode = lexer.ScanComments
+	s.Init("tests/test.html")
+	tok := s.Scan()
+	for tok != lexer.EOF {
+		if tok == lexer.Comment {
+			line := strings.ReplaceAll(s.TokenText(), "\n", " ")
+			res += strings.ReplaceAll(line, "\t", "")
+		}
+		tok = s.Scan()
+	}
+
+	want := "<!-- some unincluded comment --><!-- @todo some comment -->//@todo some javascript comment in html<!-- @todo multiline comment in  html -->"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("single line multi comments not returned without s.Match")
+	}
+}
+
+func TestUnterminatedMultiCommentEndsAtEOF(t *testing.T) {
+	res := ""
+	var s lexer.Scanner
+	s.Mode = lexer.ScanComments
+	s.InitReader("test.go", strings.NewReader("package a\n\nvar glob = \"**/\" /* not\nclosed"))
+	tok := s.Scan()
+	for tok != lexer.EOF {
+		if tok == lexer.Comment {
+			res += strings.ReplaceAll(s.TokenText(), "\n", " ")
+		}
+		tok = s.Scan()
+	}
+
+	want := "var glob = \"**/\" /* not closed"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("unterminated multi line comment not returned")
+	}
+}
+
+func TestMultiCommentAfterSingleLineMultiComment(t *testing.T) {
+	res := ""
+	var s lexer.Scanner
+	s.Mode = lexer.ScanComments
+	s.InitReader("test.go", strings.NewReader("var y = /* two */2\n/* three\n   lines */\nvar z = 3\n"))
+	tok := s.Scan()
+	for tok != lexer.EOF {
+		if tok == lexer.Comment {
+			res += strings.ReplaceAll(s.TokenText(), "\n", " ") + "|"
+		}
+		tok = s.Scan()
+	}
+
+	want := "var y = /* two */2|/* three    lines */|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("multi line comment ended by the text of an earlier comment")
+	}
+}
+
+func TestScanCode(t *testing.T) {
+	res := ""
+	var s lexer.Scanner
+	s.InitReader("test.go", strings.NewReader("package a // pkg\n\n/* block\n comment */var x = 1\n"))
+	s.Mode = lexer.ScanCode
+	tok := s.Scan()
+	for tok != lexer.EOF {
+		res += fmt.Sprintf("%s %d:%d %q|", lexer.TokenString(tok), s.Line, s.Column, s.TokenText())
+		tok = s.Scan()
+	}
+
+	want := `Code 1:1 "package a "|Comment 1:11 "// pkg"|Code 1:17 "\n\n"|Comment 3:1 "/* block\n comment */"|Code 4:12 "var x = 1\n"|`
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("code and comments not returned alternately")
+	}
+	if pos := s.Pos(); pos.Line != 5 || pos.Column != 1 || pos.Offset != 48 {
+		t.Fatalf("unexpected end position %v", pos)
+	}
+}
+
+func TestScanCodeSeveralCommentsOnALine(t *testing.T) {
+	res := ""
+	var s lexer.Scanner
+	s.InitReader("test.go", strings.NewReader("f(a /* x */, b) // y\n"))
+	s.Mode = lexer.ScanCode
+	tok := s.Scan()
+	for tok != lexer.EOF {
+		res += fmt.Sprintf("%s %d:%d %q|", lexer.TokenString(tok), s.Line, s.Column, s.TokenText())
+		tok = s.Scan()
+	}
+
+	want := `Code 1:1 "f(a "|Comment 1:5 "/* x */"|Code 1:12 ", b) "|Comment 1:17 "// y"|Code 1:21 "\n"|`
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("comment following a block comment not returned")
+	}
+}
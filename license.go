@@ -0,0 +1,221 @@
+package lexer
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by LicenseHeader.Check.
+var (
+	ErrNoHeader       = errors.New("missing licence header")
+	ErrHeaderMismatch = errors.New("licence header does not match the template")
+)
+
+// A LicenseHeader checks and writes the licence header of source files. The
+// template is the header text without comment characters; {year} and
+// {author} in it match any year, year range or list, and any author. White
+// space differences are ignored when checking.
+type LicenseHeader struct {
+	Template string
+	Year     string // year written by Fix, the current year if ""
+	Author   string // author written by Fix
+	Block    bool   // write block comments where the language has them
+
+	re *regexp.Regexp
+}
+
+// yearPattern matches a year, a range such as 2019-2024 or a list of them.
+const yearPattern = `\d{4}(?:\s*[-,–]\s*(?:\d{4}|present))*`
+
+// NewLicenseHeader returns a LicenseHeader for template.
+func NewLicenseHeader(template string) *LicenseHeader {
+	return &LicenseHeader{Template: template}
+}
+
+func (h *LicenseHeader) pattern() *regexp.Regexp {
+	if h.re == nil {
+		quoted := regexp.QuoteMeta(strings.Join(strings.Fields(h.Template), " "))
+		quoted = strings.ReplaceAll(quoted, `\{year\}`, yearPattern)
+		quoted = strings.ReplaceAll(quoted, `\{author\}`, `.+?`)
+		h.re = regexp.MustCompile(`^` + quoted + `$`)
+	}
+	return h.re
+}
+
+// Check returns ErrNoHeader if src, the contents of file, does not start with
+// a licence header and ErrHeaderMismatch if its header does not match the
+// template. The comments heading the file are the header when they match the
+// template, or else when they mention a licence or copyright. Shebang lines
+// and <?php or <?xml prologs may precede the header.
+func (h *LicenseHeader) Check(file string, src []byte) error {
+	comments := ReadComments(file, bytes.NewReader(src), "")
+	from, to := headerComments(src, comments)
+	if from == to {
+		return ErrNoHeader
+	}
+	var text []string
+	for _, c := range comments[from:to] {
+		text = append(text, bodyLines(c)...)
+	}
+	switch {
+	case h.pattern().MatchString(strings.Join(strings.Fields(strings.Join(text, "\n")), " ")):
+		return nil
+	case isLicense(comments[from:to]):
+		return ErrHeaderMismatch
+	}
+	return ErrNoHeader
+}
+
+// Fix returns src, the contents of file, with the licence header written in
+// the comment syntax of file. A header that does not match the template is
+// replaced; when there is none the header is inserted after any shebang or
+// prolog. Src is returned unchanged if its header matches.
+func (h *LicenseHeader) Fix(file string, src []byte) ([]byte, error) {
+	err := h.Check(file, src)
+	if err == nil {
+		return src, nil
+	}
+	header, err2 := h.render(filepath.Ext(file))
+	if err2 != nil {
+		return nil, err2
+	}
+	if err == ErrHeaderMismatch {
+		comments := ReadComments(file, bytes.NewReader(src), "")
+		from, to := headerComments(src, comments)
+		return ApplyEdits(src, []Edit{{Offset: comments[from].Pos.Offset, End: comments[to-1].End.Offset, NewText: header}})
+	}
+	at := prologEnd(src)
+	header += "\n"
+	if rest := src[at:]; len(rest) > 0 && !bytes.HasPrefix(rest, []byte("\n")) && !bytes.HasPrefix(rest, []byte("\r\n")) {
+		header += "\n"
+	}
+	return ApplyEdits(src, []Edit{{Offset: at, End: at, NewText: header}})
+}
+
+// render returns the header as comments for files with extension ext.
+func (h *LicenseHeader) render(ext string) (string, error) {
+	year := h.Year
+	if year == "" {
+		year = strconv.Itoa(time.Now().Year())
+	}
+	text := strings.ReplaceAll(strings.TrimSpace(h.Template), "{year}", year)
+	text = strings.ReplaceAll(text, "{author}", h.Author)
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+
+	var single, open, close string
+	for _, v := range syntaxFor(ext) {
+		if single == "" {
+			single = v.startSingle
+		}
+		if open == "" {
+			open, close = v.startMulti, v.endMulti
+		}
+	}
+	if single == "" && open == "" {
+		return "", errors.New("no comment syntax for " + ext + " files")
+	}
+	if open != "" && (h.Block || single == "") {
+		gutter := ""
+		if open == "/*" {
+			gutter = " *"
+		}
+		var b strings.Builder
+		b.WriteString(open + "\n")
+		for _, line := range lines {
+			b.WriteString(strings.TrimRight(gutter+" "+line, " ") + "\n")
+		}
+		if gutter != "" {
+			b.WriteString(" ")
+		}
+		b.WriteString(close)
+		return b.String(), nil
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(single+" "+line, " ")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// prologEnd returns the offset after the lines that must stay at the start
+// of src: a shebang line, an XML declaration and a <?php tag.
+func prologEnd(src []byte) int {
+	at := 0
+	lineEnd := func(i int) int {
+		if j := bytes.IndexByte(src[i:], '\n'); j >= 0 {
+			return i + j + 1
+		}
+		return len(src)
+	}
+	if bytes.HasPrefix(src, []byte("#!")) {
+		at = lineEnd(0)
+	}
+	rest := src[at:]
+	switch {
+	case bytes.HasPrefix(rest, []byte("<?xml")):
+		if i := bytes.Index(rest, []byte("?>")); i >= 0 {
+			at = lineEnd(at + i)
+		}
+	case bytes.HasPrefix(rest, []byte("<?php")):
+		at = lineEnd(at)
+	}
+	return at
+}
+
+// bodyLines returns the lines of the body of c without the comment
+// characters, a gutter of * in block comments and trailing white space. A
+// gutter of # or -- is removed when it starts the line comments of the file
+// and every line after the first has it.
+func bodyLines(c CommentInfo) []string {
+	start, end := bodyBounds(c)
+	lines := strings.Split(c.Text[start:end], "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if i == 0 {
+			// the rest of /** starting a doc comment
+			line = strings.TrimLeft(line, "*")
+		} else {
+			trimmed := strings.TrimLeft(line, " \t")
+			if strings.HasPrefix(trimmed, "*") && !strings.HasPrefix(trimmed, "*/") {
+				line = strings.TrimPrefix(trimmed[1:], " ")
+			}
+		}
+		lines[i] = line
+	}
+	for _, v := range syntaxFor(filepath.Ext(c.Pos.Filename)) {
+		gutter := v.startSingle
+		if gutter != "#" && gutter != "--" || !hasGutter(lines[1:], gutter) {
+			continue
+		}
+		for i := 1; i < len(lines); i++ {
+			trimmed := strings.TrimLeft(lines[i], " \t")
+			lines[i] = strings.TrimPrefix(strings.TrimPrefix(trimmed, gutter), " ")
+		}
+		break
+	}
+	return lines
+}
+
+// hasGutter reports whether every line that is not blank starts with gutter
+// after its indentation, and there is such a line.
+func hasGutter(lines []string, gutter string) bool {
+	found := false
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, gutter) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
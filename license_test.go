@@ -0,0 +1,89 @@
+package lexer_test
+
+import (
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+const licenseTemplate = "Copyright {year} {author}. All rights reserved.\nSPDX-License-Identifier: MIT"
+
+func TestLicenseHeaderCheck(t *testing.T) {
+	h := lexer.NewLicenseHeader(licenseTemplate)
+	tests := []struct {
+		file, src string
+		want      error
+	}{
+		{"a.go", "// Copyright 2024 Jane Doe. All rights reserved.\n// SPDX-License-Identifier: MIT\n\npackage a\n", nil},
+		{"a.c", "/**\n * Copyright 2019-2024, 2026 Acme Inc.\n * All rights reserved.\n *   SPDX-License-Identifier: MIT\n */\nint x;\n", nil},
+		{"a.sh", "#!/bin/sh\n# Copyright 2024 Jane Doe. All rights reserved.\n# SPDX-License-Identifier: MIT\necho\n", nil},
+		{"a.php", "<?php\n// Copyright 2024 Jane Doe. All rights reserved.\n// SPDX-License-Identifier: MIT\n", nil},
+		{"a.go", "// Package a does things.\npackage a\n", lexer.ErrNoHeader},
+		{"a.go", "package a\n\n// Copyright 2024 Jane Doe. All rights reserved.\n", lexer.ErrNoHeader},
+		{"a.go", "// Copyright 2024 Jane Doe.\n// SPDX-License-Identifier: Apache-2.0\n", lexer.ErrHeaderMismatch},
+	}
+	for _, test := range tests {
+		if err := h.Check(test.file, []byte(test.src)); err != test.want {
+			t.Errorf("%s %q: got %v, want %v", test.file, test.src, err, test.want)
+		}
+	}
+}
+
+func TestLicenseHeaderFix(t *testing.T) {
+	h := &lexer.LicenseHeader{Template: licenseTemplate, Year: "2026", Author: "Acme Inc"}
+	tests := []struct {
+		file, src, want string
+		block           bool
+	}{
+		{"a.go", "// Package a does things.\npackage a\n",
+			"// Copyright 2026 Acme Inc. All rights reserved.\n// SPDX-License-Identifier: MIT\n\n// Package a does things.\npackage a\n", false},
+		{"a.go", "package a\n",
+			"/*\n * Copyright 2026 Acme Inc. All rights reserved.\n * SPDX-License-Identifier: MIT\n */\n\npackage a\n", true},
+		{"a.sh", "#!/bin/sh\n# Copyright 2020 Old Owner\n# Licensed under GPL\necho\n",
+			"#!/bin/sh\n# Copyright 2026 Acme Inc. All rights reserved.\n# SPDX-License-Identifier: MIT\necho\n", false},
+		{"a.php", "<?php\n\necho 1;\n",
+			"<?php\n// Copyright 2026 Acme Inc. All rights reserved.\n// SPDX-License-Identifier: MIT\n\necho 1;\n", false},
+		{"a.md", "# Title\n",
+			"<!--\n Copyright 2026 Acme Inc. All rights reserved.\n SPDX-License-Identifier: MIT\n-->\n\n# Title\n", false},
+	}
+	for _, test := range tests {
+		h.Block = test.block
+		got, err := h.Fix(test.file, []byte(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: got %q, want %q", test.file, got, test.want)
+		}
+		if err := h.Check(test.file, got); err != nil {
+			t.Errorf("%s: fixed header does not pass the check: %v", test.file, err)
+		}
+	}
+}
+
+func TestLicenseHeaderFixIdempotent(t *testing.T) {
+	// the template need not mention a licence or copyright
+	h := &lexer.LicenseHeader{Template: "Proprietary and confidential. (c) {year} ACME", Year: "2026"}
+	tests := []struct{ file, src string }{
+		{"a.go", "// Package a does things.\npackage a\n"},
+		{"a.go", "package a\n"},
+		{"a.sh", "#!/bin/sh\necho\n"},
+		{"a.py", "# Copyright 2020 Old Owner\nx = 1\n"},
+	}
+	for _, test := range tests {
+		once, err := h.Fix(test.file, []byte(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Check(test.file, once); err != nil {
+			t.Errorf("%s: fixed header does not pass the check: %v", test.file, err)
+		}
+		twice, err := h.Fix(test.file, once)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(twice) != string(once) {
+			t.Errorf("%s: fixing twice gives %q, once %q", test.file, twice, once)
+		}
+	}
+}
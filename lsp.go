@@ -0,0 +1,527 @@
+package lexer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LSPServer is a language server speaking the Language Server Protocol over
+// a stream such as stdin and stdout. It provides
+//
+//	diagnostics        findings of Rules in open documents
+//	document symbols   tagged comments of a document
+//	workspace symbols  tagged comments of the open documents and of Root
+//	folding ranges     multi line block comments
+//	code actions       fixes of findings, see Rule.Replace
+//
+// Comments suppressed by directives are skipped, see Suppress. Positions are
+// exchanged in UTF-16 code units as the protocol requires.
+type LSPServer struct {
+	Rules []Rule
+	Tags  []string // tags of comments listed as symbols, DefaultTags if nil
+	Root  string   // workspace directory, the root of the client if ""
+
+	w         io.Writer
+	docs      map[string]*lspDoc       // open documents by URI
+	workspace map[string][]CommentInfo // tagged comments of Root by file, nil until scanned
+}
+
+// lspDoc is a document opened by the client.
+type lspDoc struct {
+	uri      string
+	file     string
+	text     string
+	comments []CommentInfo
+	findings []Finding
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextDocument struct {
+	URI     string `json:"uri"`
+	Text    string `json:"text"`
+	Version int    `json:"version"`
+}
+
+// LSP constants, see the specification.
+const (
+	lspSeverityWarning  = 2
+	lspSymbolString     = 15
+	lspSyncFull         = 1
+	lspMethodNotFound   = -32601
+	lspInvalidParams    = -32602
+	lspSource           = "commentlex"
+	lspFixAllActionKind = "source.fixAll.commentlex"
+)
+
+// errExit is returned by handle for the exit notification.
+var errExit = errors.New("exit")
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the client sends the exit notification or r ends.
+func (l *LSPServer) Serve(r io.Reader, w io.Writer) error {
+	l.w = w
+	l.docs = make(map[string]*lspDoc)
+	in := bufio.NewReader(r)
+	for {
+		body, err := readLSPMessage(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg struct {
+			ID     *json.RawMessage
+			Method string
+			Params json.RawMessage
+		}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return fmt.Errorf("lsp: %v", err)
+		}
+		result, err := l.handle(msg.Method, msg.Params)
+		if err == errExit {
+			return nil
+		}
+		if msg.ID == nil {
+			// notifications have no response
+			continue
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID}
+		var rpcErr *lspError
+		if errors.As(err, &rpcErr) {
+			resp["error"] = rpcErr
+		} else if err != nil {
+			resp["error"] = &lspError{Code: lspInvalidParams, Message: err.Error()}
+		} else {
+			resp["result"] = result
+		}
+		if err := l.send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// lspError is a JSON-RPC error.
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *lspError) Error() string { return e.Message }
+
+// readLSPMessage reads the body of the next message, which follows a header
+// giving its Content-Length.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lsp: reading header: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if i := strings.IndexByte(line, ':'); i >= 0 && strings.EqualFold(line[:i], "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(line[i+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("lsp: invalid header %q", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("lsp: missing Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("lsp: reading message: %v", err)
+	}
+	return body, nil
+}
+
+// send writes msg with its header.
+func (l *LSPServer) send(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(l.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// notify sends a notification to the client.
+func (l *LSPServer) notify(method string, params interface{}) error {
+	return l.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// handle answers a request or notification.
+func (l *LSPServer) handle(method string, params json.RawMessage) (interface{}, error) {
+	var p struct {
+		RootURI          string                  `json:"rootUri"`
+		RootPath         string                  `json:"rootPath"`
+		TextDocument     lspTextDocument         `json:"textDocument"`
+		ContentChanges   []struct{ Text string } `json:"contentChanges"`
+		Range            lspRange                `json:"range"`
+		Query            string                  `json:"query"`
+		WorkspaceFolders []struct {
+			URI string `json:"uri"`
+		} `json:"workspaceFolders"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+	switch method {
+	case "initialize":
+		if l.Root == "" {
+			switch {
+			case p.RootURI != "":
+				l.Root = uriFile(p.RootURI)
+			case len(p.WorkspaceFolders) > 0:
+				l.Root = uriFile(p.WorkspaceFolders[0].URI)
+			default:
+				l.Root = p.RootPath
+			}
+		}
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":        map[string]interface{}{"openClose": true, "change": lspSyncFull},
+				"documentSymbolProvider":  true,
+				"workspaceSymbolProvider": true,
+				"foldingRangeProvider":    true,
+				"codeActionProvider": map[string]interface{}{
+					"codeActionKinds": []string{"quickfix", lspFixAllActionKind},
+				},
+			},
+			"serverInfo": map[string]string{"name": lspSource},
+		}, nil
+	case "initialized", "shutdown", "textDocument/didSave":
+		return nil, nil
+	case "exit":
+		return nil, errExit
+	case "textDocument/didOpen":
+		return nil, l.update(p.TextDocument.URI, p.TextDocument.Text)
+	case "textDocument/didChange":
+		if n := len(p.ContentChanges); n > 0 {
+			return nil, l.update(p.TextDocument.URI, p.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		doc := l.docs[p.TextDocument.URI]
+		delete(l.docs, p.TextDocument.URI)
+		if doc != nil && l.workspace != nil {
+			// the file on disk may differ from the closed buffer
+			comments, _ := ScanFile(doc.file, "")
+			l.workspace[doc.file] = l.tagged(comments, comments)
+		}
+		return nil, l.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri": p.TextDocument.URI, "diagnostics": []lspDiagnostic{},
+		})
+	case "textDocument/documentSymbol":
+		return l.documentSymbols(p.TextDocument.URI), nil
+	case "workspace/symbol":
+		return l.workspaceSymbols(p.Query), nil
+	case "textDocument/foldingRange":
+		return l.foldingRanges(p.TextDocument.URI), nil
+	case "textDocument/codeAction":
+		return l.codeActions(p.TextDocument.URI, p.Range), nil
+	}
+	if strings.HasPrefix(method, "$/") {
+		// optional protocol notifications such as $/cancelRequest
+		return nil, nil
+	}
+	return nil, &lspError{Code: lspMethodNotFound, Message: "method not supported: " + method}
+}
+
+// update rescans the open document uri and publishes its diagnostics.
+func (l *LSPServer) update(uri string, text string) error {
+	doc := &lspDoc{uri: uri, file: uriFile(uri), text: text}
+	all := ReadComments(doc.file, strings.NewReader(text), "")
+	doc.comments, _ = Suppress(all, all)
+	doc.findings = CheckRules(l.Rules, doc.comments)
+	l.docs[uri] = doc
+
+	diagnostics := []lspDiagnostic{}
+	for _, f := range doc.findings {
+		diagnostics = append(diagnostics, lspDiagnostic{
+			Range:    doc.span(f.Pos.Offset, f.End.Offset),
+			Severity: lspSeverityWarning,
+			Code:     f.Rule,
+			Source:   lspSource,
+			Message:  f.Message,
+		})
+	}
+	return l.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri": uri, "diagnostics": diagnostics,
+	})
+}
+
+func (l *LSPServer) tags() []string {
+	if l.Tags == nil {
+		return DefaultTags
+	}
+	return l.Tags
+}
+
+// tagged returns the comments not suppressed by a directive in all that have
+// one of the tags of l.
+func (l *LSPServer) tagged(comments, all []CommentInfo) []CommentInfo {
+	kept, _ := Suppress(comments, all)
+	var found []CommentInfo
+	for _, c := range kept {
+		if len(c.Tags(l.tags())) > 0 {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+func (l *LSPServer) documentSymbols(uri string) interface{} {
+	symbols := []interface{}{}
+	doc := l.docs[uri]
+	if doc == nil {
+		return symbols
+	}
+	for _, c := range l.tagged(doc.comments, doc.comments) {
+		r := doc.span(c.Pos.Offset, c.End.Offset)
+		symbols = append(symbols, map[string]interface{}{
+			"name":           summary(c),
+			"detail":         strings.Join(c.Tags(l.tags()), " "),
+			"kind":           lspSymbolString,
+			"range":          r,
+			"selectionRange": r,
+		})
+	}
+	return symbols
+}
+
+func (l *LSPServer) workspaceSymbols(query string) interface{} {
+	if l.workspace == nil {
+		l.workspace = make(map[string][]CommentInfo)
+		if l.Root != "" {
+			comments, _ := ScanPaths([]string{l.Root}, "")
+			byFile := make(map[string][]CommentInfo)
+			for _, c := range comments {
+				byFile[c.Pos.Filename] = append(byFile[c.Pos.Filename], c)
+			}
+			for file, comments := range byFile {
+				l.workspace[file] = l.tagged(comments, comments)
+			}
+		}
+	}
+	open := make(map[string]*lspDoc)
+	for _, doc := range l.docs {
+		open[doc.file] = doc
+	}
+	files := make([]string, 0, len(l.workspace)+len(open))
+	for file := range l.workspace {
+		if open[file] == nil {
+			files = append(files, file)
+		}
+	}
+	for file := range open {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	query = strings.ToLower(query)
+	symbols := []interface{}{}
+	for _, file := range files {
+		comments, doc := l.workspace[file], open[file]
+		var text string
+		if doc != nil {
+			comments = l.tagged(doc.comments, doc.comments)
+		} else if len(comments) > 0 {
+			// the file is read again to count UTF-16 code units
+			src, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			text = string(src)
+		}
+		for _, c := range comments {
+			name := summary(c)
+			if !strings.Contains(strings.ToLower(name), query) {
+				continue
+			}
+			loc := lspLocation{URI: fileURI(file)}
+			if doc != nil {
+				loc.URI, loc.Range = doc.uri, doc.span(c.Pos.Offset, c.End.Offset)
+			} else {
+				loc.Range = (&lspDoc{text: text}).span(c.Pos.Offset, c.End.Offset)
+			}
+			container := file
+			if rel, err := filepath.Rel(l.Root, file); l.Root != "" && err == nil {
+				container = filepath.ToSlash(rel)
+			}
+			symbols = append(symbols, map[string]interface{}{
+				"name":          name,
+				"kind":          lspSymbolString,
+				"location":      loc,
+				"containerName": container,
+			})
+		}
+	}
+	return symbols
+}
+
+func (l *LSPServer) foldingRanges(uri string) interface{} {
+	ranges := []interface{}{}
+	doc := l.docs[uri]
+	if doc == nil {
+		return ranges
+	}
+	for _, c := range doc.comments {
+		if c.End.Line == c.Pos.Line || !isBlockComment(c) {
+			continue
+		}
+		ranges = append(ranges, map[string]interface{}{
+			"startLine": c.Pos.Line - 1,
+			"endLine":   c.End.Line - 1,
+			"kind":      "comment",
+		})
+	}
+	return ranges
+}
+
+func (l *LSPServer) codeActions(uri string, r lspRange) interface{} {
+	actions := []interface{}{}
+	doc := l.docs[uri]
+	if doc == nil {
+		return actions
+	}
+	var all []lspTextEdit
+	for _, f := range doc.findings {
+		if f.Fix == nil {
+			continue
+		}
+		edit := lspTextEdit{Range: doc.span(f.Fix.Offset, f.Fix.End), NewText: f.Fix.NewText}
+		all = append(all, edit)
+		span := doc.span(f.Pos.Offset, f.End.Offset)
+		if lspBefore(span.End, r.Start) || lspBefore(r.End, span.Start) {
+			continue
+		}
+		actions = append(actions, map[string]interface{}{
+			"title": fmt.Sprintf("Fix %s: %s", f.Rule, f.Message),
+			"kind":  "quickfix",
+			"diagnostics": []lspDiagnostic{{
+				Range: span, Severity: lspSeverityWarning, Code: f.Rule, Source: lspSource, Message: f.Message,
+			}},
+			"edit": map[string]interface{}{"changes": map[string][]lspTextEdit{uri: {edit}}},
+		})
+	}
+	if len(all) > 1 {
+		actions = append(actions, map[string]interface{}{
+			"title": "Fix all commentlex findings",
+			"kind":  lspFixAllActionKind,
+			"edit":  map[string]interface{}{"changes": map[string][]lspTextEdit{uri: all}},
+		})
+	}
+	return actions
+}
+
+// isBlockComment reports whether c starts with the characters of a multi
+// line comment.
+func isBlockComment(c CommentInfo) bool {
+	for _, v := range syntaxFor(filepath.Ext(c.Pos.Filename)) {
+		if v.startMulti != "" && strings.HasPrefix(c.Text, v.startMulti) {
+			return true
+		}
+	}
+	return false
+}
+
+// span returns the range between two byte offsets of the document.
+func (d *lspDoc) span(start, end int) lspRange {
+	return lspRange{Start: utf16Position(d.text, start), End: utf16Position(d.text, end)}
+}
+
+// utf16Position converts a byte offset of text to a zero based line and a
+// character offset counted in UTF-16 code units.
+func utf16Position(text string, offset int) lspPosition {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	start := strings.LastIndexByte(text[:offset], '\n') + 1
+	return lspPosition{
+		Line:      strings.Count(text[:start], "\n"),
+		Character: utf16Len(text[start:offset]),
+	}
+}
+
+// utf16Len returns the number of UTF-16 code units encoding s.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n++
+		if r >= 0x10000 {
+			n++
+		}
+	}
+	return n
+}
+
+func lspBefore(a, b lspPosition) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+}
+
+// uriFile returns the file name of a file URI.
+func uriFile(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// fileURI returns the file URI of file.
+func fileURI(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(file)}
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+	return u.String()
+}
@@ -0,0 +1,142 @@
+package lexer_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+// lspClient talks to an LSPServer in tests.
+type lspClient struct {
+	t   *testing.T
+	w   io.Writer
+	r   *bufio.Reader
+	ids int
+}
+
+func (c *lspClient) send(method string, params interface{}, request bool) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if request {
+		c.ids++
+		msg["id"] = c.ids
+	}
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// read returns the next message of the server.
+func (c *lspClient) read() map[string]interface{} {
+	length := 0
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "Content-Length: ") {
+			length, _ = strconv.Atoi(line[len("Content-Length: "):])
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		c.t.Fatal(err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+// call sends a request and returns the JSON encoding of its result.
+func (c *lspClient) call(method string, params interface{}) string {
+	c.send(method, params, true)
+	msg := c.read()
+	if msg["error"] != nil {
+		c.t.Fatalf("%s failed: %v", method, msg["error"])
+	}
+	res, _ := json.Marshal(msg["result"])
+	return string(res)
+}
+
+func TestLSPServer(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "b.py"), []byte("x = 1\n# TODO closed file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	replace := "FIXME"
+	rule, err := lexer.NewRule("no-xxx", `XXX`, "use FIXME", &replace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &lexer.LSPServer{Rules: []lexer.Rule{rule}}
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(inR, outW)
+		outW.Close()
+	}()
+	c := &lspClient{t: t, w: inW, r: bufio.NewReader(outR)}
+
+	rootURI := "file://" + filepath.ToSlash(root)
+	if res := c.call("initialize", map[string]interface{}{"rootUri": rootURI}); !strings.Contains(res, `"foldingRangeProvider":true`) {
+		t.Fatalf("unexpected capabilities %s", res)
+	}
+	c.send("initialized", map[string]interface{}{}, false)
+
+	uri := rootURI + "/a.go"
+	text := "package a\n\n// 😀 XXX here\n/* TODO fold\n   me */\n// commentlex:ignore-next-line\n// XXX ignored\n"
+	c.send("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "go", "version": 1, "text": text},
+	}, false)
+	diag, _ := json.Marshal(c.read())
+	want := `{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[{"code":"no-xxx","message":"use FIXME","range":{"end":{"character":9,"line":2},"start":{"character":6,"line":2}},"severity":2,"source":"commentlex"}],"uri":"` + uri + `"}}`
+	if string(diag) != want {
+		t.Fatalf("unexpected diagnostics\ngot  %s\nwant %s", diag, want)
+	}
+
+	doc := map[string]interface{}{"uri": uri}
+	if res := c.call("textDocument/documentSymbol", map[string]interface{}{"textDocument": doc}); !strings.Contains(res, `"detail":"TODO"`) || !strings.Contains(res, `"name":"TODO fold me"`) || strings.Count(res, `"name"`) != 2 {
+		t.Fatalf("unexpected document symbols %s", res)
+	}
+	if res := c.call("textDocument/foldingRange", map[string]interface{}{"textDocument": doc}); res != `[{"endLine":4,"kind":"comment","startLine":3}]` {
+		t.Fatalf("unexpected folding ranges %s", res)
+	}
+	res := c.call("textDocument/codeAction", map[string]interface{}{
+		"textDocument": doc,
+		"range":        map[string]interface{}{"start": map[string]int{"line": 2, "character": 7}, "end": map[string]int{"line": 2, "character": 7}},
+		"context":      map[string]interface{}{"diagnostics": []interface{}{}},
+	})
+	if !strings.Contains(res, `"kind":"quickfix"`) || !strings.Contains(res, `{"newText":"FIXME","range":{"end":{"character":9,"line":2},"start":{"character":6,"line":2}}}`) {
+		t.Fatalf("unexpected code actions %s", res)
+	}
+	res = c.call("workspace/symbol", map[string]interface{}{"query": "closed"})
+	if !strings.Contains(res, `"containerName":"b.py"`) || !strings.Contains(res, `"start":{"character":0,"line":1}`) || strings.Count(res, `"name"`) != 1 {
+		t.Fatalf("unexpected workspace symbols %s", res)
+	}
+	if res := c.call("workspace/symbol", map[string]interface{}{"query": ""}); strings.Count(res, `"name"`) != 3 {
+		t.Fatalf("expected symbols of open and closed files, got %s", res)
+	}
+
+	c.send("textDocument/hover", map[string]interface{}{}, true)
+	if msg := c.read(); msg["error"] == nil {
+		t.Fatalf("unsupported method should be an error, got %v", msg)
+	}
+	c.call("shutdown", nil)
+	c.send("exit", nil, false)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,260 @@
+package lexer
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Reflower wraps comment bodies to a maximum line width.
+type Reflower struct {
+	Width    int // maximum width of a line in columns
+	TabWidth int // columns of a tab, 4 if 0
+}
+
+// listItemRe matches the marker of a list item and the space after it.
+var listItemRe = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])\s+`)
+
+// reflowLine is a line of a comment: the prefix holds the indentation and
+// comment characters or gutter, the content the text after it.
+type reflowLine struct {
+	prefix, content string
+	verbatim        bool // never wrapped, such as the line closing a block comment
+}
+
+// reflowUnit is a comment, or a run of line comments, to reflow.
+type reflowUnit struct {
+	start, end int    // source offsets replaced by the reflowed text
+	indent     string // indentation of the first line, not part of its prefix
+	lines      []reflowLine
+	cont       string // prefix of lines wrapped from the first line
+	closer     string // end of a block comment following the last line
+}
+
+// Reflow returns src, the contents of file, with the paragraphs of its
+// comments that have a line longer than Width wrapped to Width, and the
+// number of comments changed. Runs of line comments alone on their lines are
+// reflowed together, as are block comments ending their line.
+//
+// The comment characters, gutters such as " * " and indentation are kept.
+// Blank lines separate paragraphs, list items start a paragraph wrapped with
+// a hanging indent and doc tags such as @param start a paragraph. Lines
+// indented further than their paragraph, fenced code blocks and tool
+// directives are never changed. Lines are only broken at
+// white space, so URLs and other long words stay intact.
+func (r Reflower) Reflow(file string, src []byte) ([]byte, int, error) {
+	comments := ReadComments(file, bytes.NewReader(src), "")
+	var edits []Edit
+	changed := 0
+	for i := 0; i < len(comments); i++ {
+		c := comments[i]
+		syntax, block := commentSyntax(c)
+		indent, alone, atLineEnd := commentPlace(src, c)
+		if !alone || !atLineEnd || isShebang(c) {
+			continue
+		}
+		var u reflowUnit
+		if block {
+			u = blockUnit(c, syntax, indent)
+		} else {
+			n := 1
+			for i+n < len(comments) {
+				next := comments[i+n]
+				s, b := commentSyntax(next)
+				nextIndent, nextAlone, nextEnd := commentPlace(src, next)
+				if b || s.startSingle != syntax.startSingle || next.Pos.Line != comments[i+n-1].Pos.Line+1 || nextIndent != indent || !nextAlone || !nextEnd {
+					break
+				}
+				n++
+			}
+			u = lineUnit(comments[i:i+n], syntax.startSingle, indent)
+			i += n - 1
+		}
+		text := r.reflowUnit(u)
+		if text != string(src[u.start:u.end]) {
+			edits = append(edits, Edit{Offset: u.start, End: u.end, NewText: text})
+			changed++
+		}
+	}
+	out, err := ApplyEdits(src, edits)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, changed, nil
+}
+
+// lineUnit returns the unit of a run of line comments starting with delim.
+func lineUnit(group []CommentInfo, delim, indent string) reflowUnit {
+	u := reflowUnit{start: group[0].Pos.Offset, end: group[len(group)-1].End.Offset, indent: indent}
+	u.cont = indent + delim + " "
+	for i, c := range group {
+		body := strings.TrimRight(strings.TrimPrefix(c.Text, delim), " \t\r")
+		prefix := delim
+		if strings.HasPrefix(body, " ") {
+			prefix, body = delim+" ", body[1:]
+		}
+		l := reflowLine{prefix: prefix, content: body}
+		// directives are written right after the comment characters
+		l.verbatim = prefix == delim && body != "" || isDirectiveText(body)
+		if i > 0 {
+			l.prefix = indent + l.prefix
+		}
+		u.lines = append(u.lines, l)
+	}
+	return u
+}
+
+// blockUnit returns the unit of block comment c.
+func blockUnit(c CommentInfo, syntax CommentValues, indent string) reflowUnit {
+	u := reflowUnit{start: c.Pos.Offset, end: c.End.Offset, indent: indent}
+	body := strings.TrimPrefix(c.Text, syntax.startMulti)
+	if strings.HasSuffix(body, syntax.endMulti) {
+		body = body[:len(body)-len(syntax.endMulti)]
+		u.closer = syntax.endMulti
+	}
+	raw := strings.Split(body, "\n")
+	for i, line := range raw {
+		line = strings.TrimRight(line, "\r")
+		var l reflowLine
+		if i == 0 {
+			// the stars of openers such as /** belong to the prefix
+			content := strings.TrimLeft(line, "* \t")
+			l.prefix, l.content = syntax.startMulti+line[:len(line)-len(content)], content
+		} else {
+			content := strings.TrimLeft(line, " \t")
+			if strings.HasPrefix(content, "*") && !strings.HasPrefix(content, syntax.endMulti) {
+				content = strings.TrimPrefix(content[1:], " ")
+			}
+			l.prefix, l.content = line[:len(line)-len(content)], content
+		}
+		if i == len(raw)-1 && u.closer != "" {
+			// the closer keeps the white space before it
+			trimmed := strings.TrimRight(l.content, " \t")
+			u.closer = l.content[len(trimmed):] + u.closer
+			l.content = trimmed
+			if i > 0 && trimmed == "" {
+				l.prefix, l.content, l.verbatim = line[:len(line)-len(strings.TrimLeft(line, " \t"))]+u.closer, "", true
+				u.closer = ""
+			}
+		}
+		u.lines = append(u.lines, l)
+	}
+	u.cont = indent + strings.Repeat(" ", utf8.RuneCountInString(u.lines[0].prefix))
+	for _, l := range u.lines[1:] {
+		if !l.verbatim {
+			u.cont = strings.TrimRight(l.prefix, " \t") + " "
+			if strings.TrimSpace(l.prefix) == "" {
+				u.cont = l.prefix
+			}
+			break
+		}
+	}
+	return u
+}
+
+// reflowUnit returns the reflowed text of u.
+func (r Reflower) reflowUnit(u reflowUnit) string {
+	var out []string
+	fenced := false
+	for i := 0; i < len(u.lines); {
+		l := u.lines[i]
+		fence := strings.HasPrefix(l.content, "```") || strings.HasPrefix(l.content, "~~~")
+		if fence {
+			fenced = !fenced
+		}
+		if fenced || fence || l.verbatim || strings.TrimSpace(l.content) == "" || l.content[0] == ' ' || l.content[0] == '\t' {
+			out = append(out, l.prefix+l.content)
+			i++
+			continue
+		}
+
+		// collect the paragraph starting at line i
+		hang := ""
+		if m := listItemRe.FindString(l.content); m != "" {
+			hang = strings.Repeat(" ", utf8.RuneCountInString(m))
+		}
+		words := strings.Fields(l.content)
+		long := r.width(u, i, l.prefix+l.content) > r.Width
+		ref := "" // prefix of the lines following the first
+		if i > 0 {
+			ref = l.prefix
+		}
+		j := i + 1
+		for ; j < len(u.lines); j++ {
+			next := u.lines[j]
+			if ref == "" {
+				ref = next.prefix
+			} else if next.prefix != ref {
+				break
+			}
+			content := next.content
+			if hang != "" && strings.HasPrefix(content, hang) {
+				content = content[len(hang):]
+			}
+			if next.verbatim || strings.TrimSpace(content) == "" || content[0] == ' ' || content[0] == '\t' ||
+				strings.HasPrefix(content, "```") || strings.HasPrefix(content, "~~~") || listItemRe.MatchString(next.content) || next.content[0] == '@' {
+				break
+			}
+			words = append(words, strings.Fields(content)...)
+			long = long || r.width(u, j, next.prefix+next.content) > r.Width
+		}
+		if !long {
+			for _, p := range u.lines[i:j] {
+				out = append(out, p.prefix+p.content)
+			}
+			i = j
+			continue
+		}
+
+		cont := u.cont
+		if i > 0 {
+			cont = l.prefix
+		}
+		cont += hang
+		line := l.prefix + words[0]
+		first := i == 0
+		for _, w := range words[1:] {
+			candidate := line + " " + w
+			col := r.columns(candidate)
+			if first {
+				col += r.columns(u.indent)
+			}
+			if col > r.Width {
+				out = append(out, line)
+				line, first = cont+w, false
+				continue
+			}
+			line = candidate
+		}
+		out = append(out, line)
+		i = j
+	}
+	out[len(out)-1] += u.closer
+	return strings.Join(out, "\n")
+}
+
+// width returns the width of line i of u in columns.
+func (r Reflower) width(u reflowUnit, i int, line string) int {
+	if i == 0 {
+		line = u.indent + line
+	}
+	return r.columns(line)
+}
+
+// columns returns the width of s in columns.
+func (r Reflower) columns(s string) int {
+	tab := r.TabWidth
+	if tab <= 0 {
+		tab = 4
+	}
+	n := 0
+	for _, ch := range s {
+		if ch == '\t' {
+			n += tab - n%tab
+			continue
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,49 @@
+package lexer_test
+
+import (
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestReflow(t *testing.T) {
+	tests := []struct {
+		file, src, want string
+		n               int
+	}{
+		{"a.go",
+			"func f() {\n\t// one two three four five six seven\n\t// eight\n\tx := 1 // trailing comments are never wrapped\n}\n",
+			"func f() {\n\t// one two three\n\t// four five six\n\t// seven eight\n\tx := 1 // trailing comments are never wrapped\n}\n", 1},
+		{"a.go",
+			"// short lines\n// stay as written\n//\n// - a list item that is too long\n// - next\n//\n//\tcode that is far too long to fit\n",
+			"// short lines\n// stay as written\n//\n// - a list item that is\n//   too long\n// - next\n//\n//\tcode that is far too long to fit\n", 1},
+		{"a.go",
+			"// see https://example.com/a/very/long/url/that/cannot/be/broken\n//go:generate a command that is far too long\n",
+			"// see\n// https://example.com/a/very/long/url/that/cannot/be/broken\n//go:generate a command that is far too long\n", 1},
+		{"a.go",
+			"/*\n * one two three four five six seven\n * ```\n * fenced code that is far too long\n * ```\n */\n",
+			"/*\n * one two three four\n * five six seven\n * ```\n * fenced code that is far too long\n * ```\n */\n", 1},
+		{"a.go",
+			"/** one two three four five six seven */\n",
+			"/** one two three four\n    five six seven */\n", 1},
+		{"a.py",
+			"    # one two three four five six\n    # seven\n",
+			"    # one two three four\n    # five six seven\n", 1},
+		{"a.go",
+			"// @param x the first argument of the call\n// @return nothing\n",
+			"// @param x the first\n// argument of the call\n// @return nothing\n", 1},
+		{"a.go",
+			"var r = `\n// a raw string line that is far too long\n`\n",
+			"var r = `\n// a raw string line that is far too long\n`\n", 0},
+		{"a.go", "// fits\n", "// fits\n", 0},
+	}
+	for _, test := range tests {
+		got, n, err := lexer.Reflower{Width: 24}.Reflow(test.file, []byte(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want || n != test.n {
+			t.Errorf("%s: got %q (%d), want %q (%d)", test.file, got, n, test.want, test.n)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package lexer
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// A Replacer replaces the matches of a regular expression in the bodies of
+// comments, leaving code, strings and comment characters alone.
+type Replacer struct {
+	Pattern *regexp.Regexp
+	Replace string // replacement template, see regexp.Regexp.Expand
+	Match   string // only replace in comments matching Match, see Scanner.Match
+}
+
+// Edits returns the edits replacing the matches of r in the bodies of
+// comments.
+func (r *Replacer) Edits(comments []CommentInfo) []Edit {
+	var edits []Edit
+	for _, c := range comments {
+		start, end := bodyBounds(c)
+		body := c.Text[start:end]
+		for _, m := range r.Pattern.FindAllStringSubmatchIndex(body, -1) {
+			edits = append(edits, Edit{
+				Offset:  c.Pos.Offset + start + m[0],
+				End:     c.Pos.Offset + start + m[1],
+				NewText: string(r.Pattern.ExpandString(nil, r.Replace, body, m)),
+			})
+		}
+	}
+	return edits
+}
+
+// ReplaceSource returns src, the contents of file, with the matches of r in
+// its comments replaced, and the number of replacements made.
+func (r *Replacer) ReplaceSource(file string, src []byte) ([]byte, int, error) {
+	edits := r.Edits(ReadComments(file, bytes.NewReader(src), r.Match))
+	out, err := ApplyEdits(src, edits)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, len(edits), nil
+}
+
+// bodyBounds returns where the body of c starts and ends in its text, which
+// leaves out the comment characters.
+func bodyBounds(c CommentInfo) (start, end int) {
+	text := c.Text
+	for _, v := range syntaxFor(filepath.Ext(c.Pos.Filename)) {
+		if v.startMulti != "" && strings.HasPrefix(text, v.startMulti) {
+			end = len(text)
+			if strings.HasSuffix(text[len(v.startMulti):], v.endMulti) {
+				end -= len(v.endMulti)
+			}
+			return len(v.startMulti), end
+		}
+	}
+	for _, v := range syntaxFor(filepath.Ext(c.Pos.Filename)) {
+		if v.startSingle != "" && strings.HasPrefix(text, v.startSingle) {
+			return len(text) - len(strings.TrimLeft(text, v.startSingle)), len(text)
+		}
+	}
+	return 0, len(text)
+}
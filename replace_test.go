@@ -0,0 +1,48 @@
+package lexer_test
+
+import (
+	"regexp"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestReplaceSource(t *testing.T) {
+	r := &lexer.Replacer{Pattern: regexp.MustCompile(`XXX|/`), Replace: "FIXME"}
+	src := "x := \"XXX\" // XXX fix a/b\n/* XXX\n   XXX */\n"
+	out, n, err := r.ReplaceSource("a.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "x := \"XXX\" // FIXME fix aFIXMEb\n/* FIXME\n   FIXME */\n"
+	if string(out) != want || n != 4 {
+		t.Fatalf("got %q with %d replacements, want %q", out, n, want)
+	}
+
+	r = &lexer.Replacer{Pattern: regexp.MustCompile(`JIRA-(\d+)`), Replace: "PROJ-$1", Match: "TODO"}
+	out, n, err = r.ReplaceSource("a.sh", []byte("# TODO JIRA-12\n# JIRA-13\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "# TODO PROJ-12\n# JIRA-13\n" || n != 1 {
+		t.Fatalf("replaced outside matching comments: %q", out)
+	}
+
+	r = &lexer.Replacer{Pattern: regexp.MustCompile(`XXX`), Replace: "FIXME"}
+	src = "var u = \"http://example.com/XXX\" // XXX fix\nvar v = `/* XXX */`\n"
+	out, n, err = r.ReplaceSource("a.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "var u = \"http://example.com/XXX\" // FIXME fix\nvar v = `/* XXX */`\n"; string(out) != want || n != 1 {
+		t.Fatalf("replaced inside a string literal: %q", out)
+	}
+
+	out, n, err = r.ReplaceSource("a.go", []byte("f(a /* XXX */, b) // XXX\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "f(a /* FIXME */, b) // FIXME\n"; string(out) != want || n != 2 {
+		t.Fatalf("comment following a block comment not replaced: %q with %d replacements", out, n)
+	}
+}
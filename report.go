@@ -0,0 +1,109 @@
+package lexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ReportFormats lists the formats understood by WriteReport.
+var ReportFormats = []string{"text", "json", "todotxt", "ical", "org"}
+
+// WriteReport writes comments to w in the given format.
+//
+// The text format prints one comment per line as file:line:column followed by
+// the comment text with its white space collapsed. The json format writes the
+// comments as a JSON array. The todotxt, ical and org formats export the
+// comments as tasks in the todo.txt, iCalendar VTODO and Org-mode formats.
+func WriteReport(w io.Writer, format string, comments []CommentInfo) error {
+	switch format {
+	case "", "text":
+		for _, c := range comments {
+			line := c.Pos.String() + ": " + normalize(c.Text)
+			if c.Blame != nil {
+				line += fmt.Sprintf(" (%s, %s)", c.Blame.Author, c.Blame.Time.Format("2006-01-02"))
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		if comments == nil {
+			comments = []CommentInfo{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(comments)
+	case "todotxt":
+		return writeTodoTxt(w, comments)
+	case "ical":
+		return writeICal(w, comments)
+	case "org":
+		return writeOrg(w, comments)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+// WriteGroups writes groups of comments, such as returned by GroupByOwner,
+// to w in the given format. The text format prints the name of each group
+// followed by its comments, the json format writes an object mapping the
+// group names to arrays of comments.
+func WriteGroups(w io.Writer, format string, groups map[string][]CommentInfo) error {
+	switch format {
+	case "", "text":
+		for _, name := range groupNames(groups) {
+			title := name
+			if title == "" {
+				title = "(none)"
+			}
+			if _, err := fmt.Fprintf(w, "%s (%d)\n", title, len(groups[name])); err != nil {
+				return err
+			}
+			if err := WriteReport(w, format, groups[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(groups)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+// WriteBudgets writes the results of CheckBudgets to w in the given format.
+// The text format prints one line per budget with the current and allowed
+// count, the json format writes the results without the counted comments.
+func WriteBudgets(w io.Writer, format string, results []BudgetResult) error {
+	switch format {
+	case "", "text":
+		for _, r := range results {
+			status := "ok"
+			if r.Exceeded() {
+				status = "exceeded"
+			}
+			if _, err := fmt.Fprintf(w, "%s %s: %d/%d %s\n", r.Budget.Pattern, r.Budget.Tag, r.Count, r.Budget.Max, status); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		type result struct {
+			Pattern  string
+			Tag      string
+			Max      int
+			Count    int
+			Exceeded bool
+		}
+		out := make([]result, len(results))
+		for i, r := range results {
+			out[i] = result{r.Budget.Pattern, r.Budget.Tag, r.Budget.Max, r.Count, r.Exceeded()}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
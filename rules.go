@@ -0,0 +1,142 @@
+package lexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// A Rule reports the comments containing text matched by Pattern. Rules
+// with a Replace template can be fixed by replacing each match with the
+// expanded template, see regexp.Regexp.Expand.
+type Rule struct {
+	Name    string  // short name shown with the findings of the rule
+	Pattern string  // regular expression for text a comment must not contain
+	Message string  // explains the finding
+	Replace *string `json:",omitempty"` // fix for each match, nil if the rule cannot be fixed
+
+	re *regexp.Regexp
+}
+
+// Finding is a match of a Rule in a comment.
+type Finding struct {
+	Rule    string
+	Message string
+	Comment CommentInfo
+	Pos     Position // start of the match
+	End     Position // position immediately after the match
+	Fix     *Edit    `json:",omitempty"`
+}
+
+// Edit replaces the text between two byte offsets of a file.
+type Edit struct {
+	Offset  int // byte offset of the first replaced byte
+	End     int // byte offset immediately after the replaced text
+	NewText string
+}
+
+// NewRule returns a Rule for pattern. Replace is the fix template, nil if the
+// rule cannot be fixed.
+func NewRule(name string, pattern string, message string, replace *string) (Rule, error) {
+	r := Rule{Name: name, Pattern: pattern, Message: message, Replace: replace}
+	return r, r.compile()
+}
+
+func (r *Rule) compile() error {
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("rule %s: %v", r.Name, err)
+	}
+	r.re = re
+	return nil
+}
+
+// LoadRules reads the rules in file, see ParseRules.
+func LoadRules(file string) ([]Rule, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseRules(f)
+}
+
+// ParseRules reads a JSON array of rules, such as
+//
+//	[
+//		{"Name": "todo-owner", "Pattern": "TODO[^(]", "Message": "write TODO(owner)"},
+//		{"Name": "no-xxx", "Pattern": "\\bXXX\\b", "Message": "use FIXME", "Replace": "FIXME"}
+//	]
+func ParseRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("rules: %v", err)
+	}
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// Check returns the findings of rule r in comment c.
+func (r *Rule) Check(c CommentInfo) []Finding {
+	if r.re == nil {
+		if err := r.compile(); err != nil {
+			return nil
+		}
+	}
+	var findings []Finding
+	for _, m := range r.re.FindAllStringSubmatchIndex(c.Text, -1) {
+		f := Finding{
+			Rule:    r.Name,
+			Message: r.Message,
+			Comment: c,
+			Pos:     advance(c.Pos, c.Text[:m[0]]),
+		}
+		f.End = advance(f.Pos, c.Text[m[0]:m[1]])
+		if r.Replace != nil {
+			text := string(r.re.ExpandString(nil, *r.Replace, c.Text, m))
+			f.Fix = &Edit{Offset: f.Pos.Offset, End: f.End.Offset, NewText: text}
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// CheckRules returns the findings of every rule in comments, ordered by
+// comment.
+func CheckRules(rules []Rule, comments []CommentInfo) []Finding {
+	var findings []Finding
+	for _, c := range comments {
+		for i := range rules {
+			findings = append(findings, rules[i].Check(c)...)
+		}
+	}
+	return findings
+}
+
+// ApplyEdits returns src with edits applied. Edits must not overlap; they
+// may be given in any order.
+func ApplyEdits(src []byte, edits []Edit) ([]byte, error) {
+	sorted := append([]Edit(nil), edits...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j].Offset < sorted[j-1].Offset; j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	var out []byte
+	last := 0
+	for _, e := range sorted {
+		if e.Offset < last || e.End < e.Offset || e.End > len(src) {
+			return nil, fmt.Errorf("invalid or overlapping edit at offset %d", e.Offset)
+		}
+		out = append(out, src[last:e.Offset]...)
+		out = append(out, e.NewText...)
+		last = e.End
+	}
+	return append(out, src[last:]...), nil
+}
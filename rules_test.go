@@ -0,0 +1,57 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestCheckRules(t *testing.T) {
+	rules, err := lexer.ParseRules(strings.NewReader(`[
+		{"Name": "todo-owner", "Pattern": "TODO[^(]", "Message": "write TODO(owner)"},
+		{"Name": "no-xxx", "Pattern": "\\bXXX(\\w*)", "Message": "use FIXME", "Replace": "FIXME$1"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := "x := 1 // TODO fix\n/* XXXa and\n   XXX */\n// TODO(jane) fine\n"
+	comments := lexer.ReadComments("a.go", strings.NewReader(src), "")
+	findings := lexer.CheckRules(rules, comments)
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %v", findings)
+	}
+	if f := findings[0]; f.Rule != "todo-owner" || f.Pos.Line != 1 || f.Pos.Column != 11 || f.Fix != nil {
+		t.Fatalf("unexpected first finding %+v", f)
+	}
+	if f := findings[2]; f.Pos.Line != 3 || f.Pos.Column != 4 || f.End.Column != 7 {
+		t.Fatalf("unexpected position of finding on second line %+v", f)
+	}
+
+	var edits []lexer.Edit
+	for _, f := range findings {
+		if f.Fix != nil {
+			edits = append(edits, *f.Fix)
+		}
+	}
+	fixed, err := lexer.ApplyEdits([]byte(src), edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "x := 1 // TODO fix\n/* FIXMEa and\n   FIXME */\n// TODO(jane) fine\n"; string(fixed) != want {
+		t.Fatalf("fixes not applied as expected, got %q", fixed)
+	}
+}
+
+func TestParseRulesRejectsInvalidPatterns(t *testing.T) {
+	if _, err := lexer.ParseRules(strings.NewReader(`[{"Name": "bad", "Pattern": "("}]`)); err == nil {
+		t.Fatalf("invalid pattern should be an error")
+	}
+}
+
+func TestApplyEditsRejectsOverlaps(t *testing.T) {
+	_, err := lexer.ApplyEdits([]byte("abcdef"), []lexer.Edit{{Offset: 2, End: 4}, {Offset: 1, End: 3}})
+	if err == nil {
+		t.Fatalf("overlapping edits should be an error")
+	}
+}
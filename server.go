@@ -0,0 +1,229 @@
+package lexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScanRequest is the JSON body of a POST /scan request. The comment syntax
+// is chosen by Language, a file extension such as "go" or ".go", or else by
+// the extension of Filename.
+type ScanRequest struct {
+	Filename string
+	Language string
+	Source   string
+	Match    string
+}
+
+// A Server answers HTTP requests for comments. It serves
+//
+//	POST /scan      scan the source in the body, see ScanRequest
+//	GET  /comments  comments of the last scan of Repo, filtered by the path
+//	                (a file or directory), tag and match query parameters
+//	GET  /healthz   health check
+//	GET  /metrics   counters in the Prometheus text format
+//
+// POST /scan also accepts the plain source as body with the filename,
+// language and match given as query parameters.
+type Server struct {
+	Repo          string // directory scanned by Rescan, "" disables GET /comments
+	Match         string // match used when scanning Repo, see Scanner.Match
+	MaxBodySize   int64  // largest accepted request body in bytes, 1 MiB if 0
+	MaxConcurrent int    // scans running at the same time, the number of CPUs if 0
+
+	once    sync.Once
+	slots   chan struct{} // one value per running scan
+	pool    sync.Pool     // *Scanner
+	mu      sync.RWMutex
+	last    []CommentInfo // comments of the last scan of Repo
+	scanned time.Time     // time of the last scan of Repo
+
+	requests, scans, failures, returned int64
+}
+
+func (s *Server) init() {
+	s.once.Do(func() {
+		n := s.MaxConcurrent
+		if n <= 0 {
+			n = runtime.NumCPU()
+		}
+		s.slots = make(chan struct{}, n)
+		s.pool.New = func() interface{} { return new(Scanner) }
+	})
+}
+
+// Rescan scans Repo and keeps the result for GET /comments.
+func (s *Server) Rescan() error {
+	if s.Repo == "" {
+		return nil
+	}
+	comments, err := ScanPaths([]string{s.Repo}, s.Match)
+	if err != nil {
+		atomic.AddInt64(&s.failures, 1)
+		return err
+	}
+	for i := range comments {
+		if rel, err := filepath.Rel(s.Repo, comments[i].Pos.Filename); err == nil {
+			comments[i].Pos.Filename, comments[i].End.Filename = filepath.ToSlash(rel), filepath.ToSlash(rel)
+		}
+	}
+	s.mu.Lock()
+	s.last, s.scanned = comments, time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.init()
+	atomic.AddInt64(&s.requests, 1)
+	switch r.URL.Path {
+	case "/scan":
+		if r.Method != http.MethodPost {
+			httpError(w, http.StatusMethodNotAllowed, "use POST")
+			return
+		}
+		s.serveScan(w, r)
+	case "/comments":
+		s.serveComments(w, r)
+	case "/healthz":
+		io.WriteString(w, "ok\n")
+	case "/metrics":
+		s.serveMetrics(w)
+	default:
+		httpError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) serveScan(w http.ResponseWriter, r *http.Request) {
+	max := s.MaxBodySize
+	if max <= 0 {
+		max = 1 << 20
+	}
+	// read one byte more than allowed to tell a body that is too large from
+	// one that could not be read
+	body, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "reading the request: "+err.Error())
+		return
+	}
+	if int64(len(body)) > max {
+		httpError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body larger than %d bytes", max))
+		return
+	}
+	var req ScanRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(body, &req); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		req = ScanRequest{Filename: q.Get("filename"), Language: q.Get("language"), Match: q.Get("match"), Source: string(body)}
+	}
+	name := req.Filename
+	if req.Language != "" {
+		name = "input." + strings.TrimPrefix(req.Language, ".")
+	}
+	if !Supported(name) {
+		httpError(w, http.StatusBadRequest, "unsupported language, give a filename or language with a supported extension")
+		return
+	}
+
+	// wait for a free slot until the client gives up
+	select {
+	case s.slots <- struct{}{}:
+	case <-r.Context().Done():
+		httpError(w, http.StatusServiceUnavailable, "too many scans")
+		return
+	}
+	sc := s.pool.Get().(*Scanner)
+	sc.Match = req.Match
+	sc.InitReader(name, strings.NewReader(req.Source))
+	comments := sc.Comments(req.Filename)
+	s.pool.Put(sc)
+	<-s.slots
+
+	atomic.AddInt64(&s.scans, 1)
+	atomic.AddInt64(&s.returned, int64(len(comments)))
+	writeJSON(w, comments)
+}
+
+func (s *Server) serveComments(w http.ResponseWriter, r *http.Request) {
+	if s.Repo == "" {
+		httpError(w, http.StatusNotFound, "no repository configured")
+		return
+	}
+	q := r.URL.Query()
+	path, tag, match := q.Get("path"), q.Get("tag"), q.Get("match")
+	s.mu.RLock()
+	last := s.last
+	s.mu.RUnlock()
+	var comments []CommentInfo
+	for _, c := range last {
+		if inPath(c.Pos.Filename, path) && (tag == "" || c.HasTag(tag)) && strings.Contains(c.Text, match) {
+			comments = append(comments, c)
+		}
+	}
+	atomic.AddInt64(&s.returned, int64(len(comments)))
+	writeJSON(w, comments)
+}
+
+// inPath reports whether file, a slash separated path relative to the
+// repository, is dir or below it. Every file is below an empty dir.
+func inPath(file, dir string) bool {
+	dir = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(dir)), "/")
+	return dir == "." || dir == "" || file == dir || strings.HasPrefix(file, dir+"/")
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter) {
+	s.mu.RLock()
+	stored, scanned := len(s.last), s.scanned
+	s.mu.RUnlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, m := range []struct {
+		name, kind, help string
+		value            int64
+	}{
+		{"commentlex_requests_total", "counter", "HTTP requests received.", atomic.LoadInt64(&s.requests)},
+		{"commentlex_scans_total", "counter", "Sources scanned through POST /scan.", atomic.LoadInt64(&s.scans)},
+		{"commentlex_scan_failures_total", "counter", "Failed scans of the repository.", atomic.LoadInt64(&s.failures)},
+		{"commentlex_comments_returned_total", "counter", "Comments returned in responses.", atomic.LoadInt64(&s.returned)},
+		{"commentlex_repository_comments", "gauge", "Comments found by the last scan of the repository.", int64(stored)},
+		{"commentlex_repository_scan_timestamp_seconds", "gauge", "Time of the last scan of the repository.", unixOrZero(scanned)},
+		{"commentlex_scans_in_progress", "gauge", "Scans running right now.", int64(len(s.slots))},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+	}
+}
+
+// unixOrZero returns t as Unix time, or 0 for the zero time.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// writeJSON writes comments as the JSON response, an empty array for nil.
+func writeJSON(w http.ResponseWriter, comments []CommentInfo) {
+	if comments == nil {
+		comments = []CommentInfo{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+// httpError writes msg as a JSON error response with status code.
+func httpError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"Error": msg})
+}
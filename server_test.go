@@ -0,0 +1,96 @@
+package lexer_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestServerScan(t *testing.T) {
+	srv := httptest.NewServer(&lexer.Server{MaxBodySize: 100})
+	defer srv.Close()
+	post := func(url, contentType, body string) (*http.Response, []lexer.CommentInfo) {
+		resp, err := http.Post(srv.URL+url, contentType, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var comments []lexer.CommentInfo
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&comments); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp, comments
+	}
+
+	resp, comments := post("/scan", "application/json", `{"filename":"x","language":"lua","source":"--@todo one\n-- two\n","match":"@todo"}`)
+	if resp.StatusCode != http.StatusOK || len(comments) != 1 || comments[0].Text != "--@todo one" || comments[0].Pos.Filename != "x" {
+		t.Fatalf("unexpected JSON scan result %d %v", resp.StatusCode, comments)
+	}
+	resp, comments = post("/scan?filename=a.sh", "text/plain", "echo # one\n# two\n")
+	if resp.StatusCode != http.StatusOK || len(comments) != 2 {
+		t.Fatalf("unexpected plain scan result %d %v", resp.StatusCode, comments)
+	}
+	if resp, _ := post("/scan?filename=a.go", "text/plain", strings.Repeat("// x\n", 50)); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body over the limit answered with %d", resp.StatusCode)
+	}
+	if resp, _ := post("/scan?filename=a.unknown", "text/plain", "x"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unsupported language answered with %d", resp.StatusCode)
+	}
+	rec := httptest.NewRecorder()
+	(&lexer.Server{}).ServeHTTP(rec, httptest.NewRequest("POST", "/scan?filename=a.go", iotest.ErrReader(errors.New("broken"))))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unreadable body answered with %d", rec.Code)
+	}
+}
+
+func TestServerComments(t *testing.T) {
+	repo := t.TempDir()
+	os.MkdirAll(filepath.Join(repo, "api"), 0755)
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("// TODO main\n// plain\n"), 0644)
+	os.WriteFile(filepath.Join(repo, "api", "api.go"), []byte("// FIXME api\n"), 0644)
+	os.MkdirAll(filepath.Join(repo, "apix"), 0755)
+	os.WriteFile(filepath.Join(repo, "apix", "x.go"), []byte("// other\n"), 0644)
+	s := &lexer.Server{Repo: repo}
+	if err := s.Rescan(); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(url string) string {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		return rec.Body.String()
+	}
+	res := ""
+	for _, url := range []string{"/comments?tag=TODO", "/comments?path=api/", "/comments?path=api", "/comments?match=plain"} {
+		var comments []lexer.CommentInfo
+		if err := json.Unmarshal([]byte(get(url)), &comments); err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range comments {
+			res += c.Pos.String() + " "
+		}
+	}
+
+	want := "main.go:1:1 api/api.go:1:1 api/api.go:1:1 main.go:2:1 "
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("comments not filtered as expected")
+	}
+	if got := get("/healthz"); got != "ok\n" {
+		t.Fatalf("unexpected health check %q", got)
+	}
+	if got := get("/metrics"); !strings.Contains(got, "commentlex_repository_comments 4\n") {
+		t.Fatalf("unexpected metrics\n%s", got)
+	}
+}
@@ -0,0 +1,71 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ScanStaged returns the comments on the lines staged for the next commit in
+// the git repository at dir. The staged contents are read from the index, so
+// changes that are not staged do not affect the result. File names are
+// relative to dir.
+func ScanStaged(dir string, match string) ([]CommentInfo, error) {
+	changed, err := StagedDiff(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files, blobs []string
+	for file := range changed {
+		if len(changed[file]) > 0 && Supported(file) {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		// :./path names the staged blob of a path relative to dir
+		blobs = append(blobs, ":./"+file)
+	}
+	var comments []CommentInfo
+	err = gitBlobs(dir, blobs, func(i int, data []byte) error {
+		comments = append(comments, ReadComments(files[i], bytes.NewReader(data), match)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed.Filter(comments), nil
+}
+
+// hookMarker identifies pre-commit hooks written by InstallHook.
+const hookMarker = "# installed by commentlex install-hook"
+
+// InstallHook writes a pre-commit hook into the git repository at dir that
+// runs the given commentlex command line, such as
+// "commentlex staged -match @todo". An existing hook that was not written by
+// InstallHook is only replaced if force is set. It returns the path of the
+// hook.
+func InstallHook(dir string, command string, force bool) (string, error) {
+	out, err := git(dir, "rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return "", err
+	}
+	hook := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(hook) {
+		hook = filepath.Join(dir, hook)
+	}
+	if old, err := os.ReadFile(hook); err == nil && !force && !bytes.Contains(old, []byte(hookMarker)) {
+		return "", fmt.Errorf("%s already exists, use force to replace it", hook)
+	}
+	if err := os.MkdirAll(filepath.Dir(hook), 0755); err != nil {
+		return "", err
+	}
+	script := "#!/bin/sh\n" + hookMarker + "\nexec " + command + "\n"
+	if err := os.WriteFile(hook, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	return hook, os.Chmod(hook, 0755)
+}
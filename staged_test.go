@@ -0,0 +1,66 @@
+package lexer_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestScanStagedReadsIndex(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"pkg/a.go": "package a\n\n// TODO committed\n"})
+	file := filepath.Join(dir, "pkg", "a.go")
+	if err := os.WriteFile(file, []byte("package a\n\n// TODO committed\n// TODO staged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "pkg/a.go")
+	// unstaged change that must not be reported
+	if err := os.WriteFile(file, []byte("package a\n\n// TODO committed\n// TODO staged\n// TODO not staged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	comments, err := lexer.ScanStaged(filepath.Join(dir, "pkg"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := ""
+	for _, c := range comments {
+		res += fmt.Sprintf("%s %s|", c.Pos, c.Text)
+	}
+
+	want := "a.go:4:1 // TODO staged|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("staged comments not as expected")
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.go": "package a\n"})
+	hook, err := lexer.InstallHook(dir, "commentlex staged -match @todo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "#!/bin/sh\n") || !strings.Contains(string(data), "exec commentlex staged -match @todo\n") {
+		t.Fatalf("unexpected hook\n%s", data)
+	}
+
+	// our own hook may be replaced, any other hook only with force
+	if _, err := lexer.InstallHook(dir, "commentlex staged", false); err != nil {
+		t.Fatalf("reinstalling the hook failed: %v", err)
+	}
+	os.WriteFile(hook, []byte("#!/bin/sh\nmake lint\n"), 0755)
+	if _, err := lexer.InstallHook(dir, "commentlex staged", false); err == nil {
+		t.Fatalf("existing hook was replaced without force")
+	}
+	if _, err := lexer.InstallHook(dir, "commentlex staged", true); err != nil {
+		t.Fatal(err)
+	}
+}
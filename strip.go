@@ -0,0 +1,173 @@
+package lexer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToolDirectives lists the starts of comment texts, without the comment
+// characters, that hold directives for other tools. Stripper keeps these
+// comments when KeepDirectives is set.
+var ToolDirectives = []string{
+	DirectivePrefix,
+	"go:", "+build", "line ", "nolint", // Go
+	"shellcheck ", "-*-", "noqa", "type:", "pylint:", "rubocop:", // shell, Python, Ruby
+	"eslint", "prettier-ignore", "@ts-", "istanbul ", "jshint", // JavaScript
+	"@license", "@preserve", "!", // kept by minifiers
+}
+
+// A Stripper removes comments from source code.
+type Stripper struct {
+	// Preserve replaces comments with spaces and keeps their line breaks, so
+	// the rest of the source keeps its lines and columns.
+	Preserve bool
+	// KeepLicense keeps the comments heading the file when one of them
+	// mentions a licence or copyright.
+	KeepLicense bool
+	// KeepDirectives keeps comments holding directives, see ToolDirectives.
+	KeepDirectives bool
+}
+
+// Strip copies r to w without its comments, see Stripper. Lang is a file
+// extension such as "go" or ".go" choosing the comment syntax.
+func Strip(w io.Writer, r io.Reader, lang string) error {
+	return Stripper{}.Strip(w, r, lang)
+}
+
+// Strip copies r to w without its comments. Lang is a file extension such as
+// "go" or ".go" choosing the comment syntax. Shebang lines are always kept.
+// Lines holding nothing but a comment are removed unless Preserve is set.
+func (s Stripper) Strip(w io.Writer, r io.Reader, lang string) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	file := "input." + strings.TrimPrefix(lang, ".")
+	comments := ReadComments(file, bytes.NewReader(src), "")
+	from, to := 0, 0
+	if s.KeepLicense {
+		if from, to = headerComments(src, comments); !isLicense(comments[from:to]) {
+			from, to = 0, 0
+		}
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for i, c := range comments {
+		if i >= from && i < to || isShebang(c) || s.KeepDirectives && isToolDirective(c) {
+			continue
+		}
+		start, end := c.Pos.Offset, c.End.Offset
+		atLineEnd := end == len(src) || src[end] == '\n' || src[end] == '\r'
+		out.Write(src[last:start])
+		last = end
+		if atLineEnd {
+			// the white space before a comment ending its line is dropped
+			out.Truncate(len(bytes.TrimRight(out.Bytes(), " \t")))
+		}
+		// the line so far, without the comments removed from it
+		line := out.Bytes()[bytes.LastIndexByte(out.Bytes(), '\n')+1:]
+		switch {
+		case s.Preserve:
+			out.WriteString(blank(c.Text, atLineEnd))
+		case atLineEnd && len(line) == 0:
+			// the line held nothing but comments
+			last = skipNewline(src, end)
+		case !atLineEnd && len(line) > 0 && !isSpace(line[len(line)-1]) && !isSpace(src[end]):
+			// keep the code around an inline comment apart
+			out.WriteByte(' ')
+		}
+	}
+	out.Write(src[last:])
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// headerComments returns the range of comments forming the header of src:
+// the first comment when nothing but white space or a prolog precedes it, see
+// prologEnd, and the comments following it without a blank line or code in
+// between.
+func headerComments(src []byte, comments []CommentInfo) (from, to int) {
+	last := prologEnd(src)
+	for from < len(comments) && comments[from].Pos.Offset < last {
+		from++
+	}
+	for to = from; to < len(comments); to++ {
+		gap := src[last:comments[to].Pos.Offset]
+		if len(bytes.TrimSpace(gap)) > 0 || to > from && bytes.Count(gap, []byte("\n")) > 1 {
+			break
+		}
+		last = comments[to].End.Offset
+	}
+	return from, to
+}
+
+func isShebang(c CommentInfo) bool {
+	return c.Pos.Offset == 0 && strings.HasPrefix(c.Text, "#!")
+}
+
+// isLicense reports whether one of comments mentions a licence or copyright.
+func isLicense(comments []CommentInfo) bool {
+	for _, c := range comments {
+		text := strings.ToLower(c.Text)
+		for _, word := range []string{"license", "licence", "copyright", "spdx-license-identifier"} {
+			if strings.Contains(text, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isToolDirective reports whether c holds a directive of ToolDirectives.
+func isToolDirective(c CommentInfo) bool {
+	return isDirectiveText(summary(c))
+}
+
+// isDirectiveText reports whether text, a comment without its comment
+// characters, starts with a directive of ToolDirectives.
+func isDirectiveText(text string) bool {
+	for _, d := range ToolDirectives {
+		if strings.HasPrefix(text, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// blank returns text with its characters replaced by spaces, keeping its line
+// breaks. Spaces that would end a line are left out.
+func blank(text string, atLineEnd bool) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case i < len(lines)-1:
+			lines[i] = ""
+			if strings.HasSuffix(line, "\r") {
+				lines[i] = "\r"
+			}
+		case atLineEnd:
+			lines[i] = ""
+		default:
+			lines[i] = strings.Repeat(" ", utf8.RuneCountInString(line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// skipNewline returns the offset after the line break at offset i of src.
+func skipNewline(src []byte, i int) int {
+	if i < len(src) && src[i] == '\r' {
+		i++
+	}
+	if i < len(src) && src[i] == '\n' {
+		i++
+	}
+	return i
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
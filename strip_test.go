@@ -0,0 +1,71 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func strip(t *testing.T, s lexer.Stripper, src, lang string) string {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Strip(&out, strings.NewReader(src), lang); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestStrip(t *testing.T) {
+	src := "// Copyright 2024 Jane Doe\n// SPDX-License-Identifier: MIT\n\n// Package a does things.\npackage a\n\n//go:generate stringer\nvar x = 1 // one\nvar y = /* two */2\n/* three\n   lines */\nvar z = 3\n"
+
+	if got, want := strip(t, lexer.Stripper{}, src, "go"), "\npackage a\n\nvar x = 1\nvar y = 2\nvar z = 3\n"; got != want {
+		t.Fatalf("unexpected stripped source\ngot  %q\nwant %q", got, want)
+	}
+	keep := lexer.Stripper{KeepLicense: true, KeepDirectives: true}
+	if got, want := strip(t, keep, src, ".go"), "// Copyright 2024 Jane Doe\n// SPDX-License-Identifier: MIT\n\npackage a\n\n//go:generate stringer\nvar x = 1\nvar y = 2\nvar z = 3\n"; got != want {
+		t.Fatalf("licence header or directive not kept\ngot  %q\nwant %q", got, want)
+	}
+
+	preserved := strip(t, lexer.Stripper{Preserve: true}, src, "go")
+	if got, want := strings.Count(preserved, "\n"), strings.Count(src, "\n"); got != want {
+		t.Fatalf("expected %d lines, got %d in %q", want, got, preserved)
+	}
+	lines := strings.Split(preserved, "\n")
+	if lines[7] != "var x = 1" || lines[8] != "var y =          2" || lines[10] != "" {
+		t.Fatalf("positions not preserved in %q", preserved)
+	}
+}
+
+func TestStripKeepsShebang(t *testing.T) {
+	src := "#!/bin/sh\n# a script\necho hi # greet\n"
+	if got, want := strip(t, lexer.Stripper{}, src, "sh"), "#!/bin/sh\necho hi\n"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+	// only a licence is kept as header
+	if got := strip(t, lexer.Stripper{KeepLicense: true}, src, "sh"); got != "#!/bin/sh\necho hi\n" {
+		t.Fatalf("comment without licence kept as header: %q", got)
+	}
+}
+
+func TestStripSkipsLiterals(t *testing.T) {
+	src := "var u = \"http://example.com/XXX\" // XXX fix\nvar v = \"/* not a comment */\"\nvar r = `\n// raw\n`\nvar c = '\"' // quote\nvar e = \"\\\"//\" /* escaped */\n"
+	want := "var u = \"http://example.com/XXX\"\nvar v = \"/* not a comment */\"\nvar r = `\n// raw\n`\nvar c = '\"'\nvar e = \"\\\"//\"\n"
+	if got := strip(t, lexer.Stripper{}, src, "go"); got != want {
+		t.Fatalf("literals not kept\ngot  %q\nwant %q", got, want)
+	}
+	src = "echo 'it''s # not' # comment\nx=\"# no\"\n"
+	if got, want := strip(t, lexer.Stripper{}, src, "sh"), "echo 'it''s # not'\nx=\"# no\"\n"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestStripSeveralCommentsOnALine(t *testing.T) {
+	src := "f(a /* x */, b /* y */)\nb := 2 /* two */ // three\n/* a */ /* b */\nc := 3\n"
+	if got, want := strip(t, lexer.Stripper{}, src, "go"), "f(a , b )\nb := 2\nc := 3\n"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+	if got, want := strip(t, lexer.Stripper{Preserve: true}, src, "go"), "f(a        , b        )\nb := 2\n\nc := 3\n"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package lexer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// DirectivePrefix starts a directive in a comment. The supported directives
+// are
+//
+//	commentlex:ignore            suppress the comment holding the directive
+//	commentlex:ignore-next-line  suppress comments starting on the next line
+//	commentlex:disable           suppress comments until commentlex:enable
+//	commentlex:enable            end a commentlex:disable block
+//
+// Directives work in any comment syntax the scanner knows about.
+const DirectivePrefix = "commentlex:"
+
+// directive is a directive found in a comment, with the lines it suppresses.
+type directive struct {
+	comment     CommentInfo
+	name        string
+	first, last int // suppressed lines, last < 0 means to the end of the file
+	used        bool
+}
+
+// Suppress removes the comments suppressed by directives from comments and
+// returns the rest. Comments holding a directive are never returned. Because
+// directives are usually not matched by Scanner.Match, they are looked up in
+// all, which holds every comment of the same files; all may be nil when
+// comments was scanned without Match.
+//
+// Suppress also returns the directive comments that did not suppress any of
+// comments, so stale directives can be reported.
+func Suppress(comments, all []CommentInfo) (kept, unused []CommentInfo) {
+	if all == nil {
+		all = comments
+	}
+	directives := make(map[string][]*directive)
+	var order []*directive
+	for _, d := range findDirectives(all) {
+		file := filepath.Clean(d.comment.Pos.Filename)
+		directives[file] = append(directives[file], d)
+		order = append(order, d)
+	}
+
+	for _, c := range comments {
+		suppressed := false
+		for _, d := range directives[filepath.Clean(c.Pos.Filename)] {
+			if d.comment.Pos.Offset == c.Pos.Offset {
+				// the directive comment itself
+				suppressed = true
+				if d.name == "ignore" {
+					d.used = true
+				}
+				continue
+			}
+			if c.Pos.Line >= d.first && (d.last < 0 || c.Pos.Line <= d.last) {
+				suppressed = true
+				d.used = true
+			}
+		}
+		if !suppressed {
+			kept = append(kept, c)
+		}
+	}
+
+	for _, d := range order {
+		if !d.used && d.name != "enable" {
+			unused = append(unused, d.comment)
+		}
+	}
+	return kept, unused
+}
+
+// findDirectives returns the directives in comments in the order found. The
+// lines suppressed by disable run up to the next enable in the same file.
+func findDirectives(comments []CommentInfo) []*directive {
+	var found []*directive
+	open := make(map[string]*directive) // disable directive per file waiting for its enable
+	for _, c := range comments {
+		name := directiveName(c.Text)
+		if name == "" {
+			continue
+		}
+		file := filepath.Clean(c.Pos.Filename)
+		d := &directive{comment: c, name: name, first: -1, last: -1}
+		switch name {
+		case "ignore":
+			// only the comment itself, which is matched by offset
+			d.first, d.last = 0, 0
+		case "ignore-next-line":
+			d.first, d.last = c.End.Line+1, c.End.Line+1
+		case "disable":
+			d.first = c.End.Line + 1
+			open[file] = d
+		case "enable":
+			if o := open[file]; o != nil {
+				o.last = c.Pos.Line - 1
+				delete(open, file)
+			}
+			d.first, d.last = 0, 0
+		default:
+			continue
+		}
+		found = append(found, d)
+	}
+	return found
+}
+
+// directiveName returns the name of the first directive in text, or "" if
+// text holds no known directive.
+func directiveName(text string) string {
+	for i := 0; ; {
+		j := strings.Index(text[i:], DirectivePrefix)
+		if j < 0 {
+			return ""
+		}
+		i += j + len(DirectivePrefix)
+		end := i
+		for end < len(text) && (text[end] == '-' || text[end] >= 'a' && text[end] <= 'z') {
+			end++
+		}
+		switch name := text[i:end]; name {
+		case "ignore", "ignore-next-line", "disable", "enable":
+			return name
+		}
+	}
+}
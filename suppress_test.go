@@ -0,0 +1,61 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestSuppressDirectives(t *testing.T) {
+	src := `package a
+
+// TODO reported
+// TODO kept forever commentlex:ignore
+// commentlex:ignore-next-line
+// TODO ignored by the previous line
+/* commentlex:disable */
+// TODO inside a disabled block
+// FIXME also inside
+// commentlex:enable
+# commentlex:ignore-next-line
+// TODO after the block
+`
+	all := lexer.ReadComments("a.go", strings.NewReader(src), "")
+	var matched []lexer.CommentInfo
+	for _, c := range all {
+		if c.HasTag("TODO") {
+			matched = append(matched, c)
+		}
+	}
+	kept, unused := lexer.Suppress(matched, all)
+
+	res := ""
+	for _, c := range kept {
+		res += fmt.Sprintf("%d %s|", c.Pos.Line, c.Text)
+	}
+	want := "3 // TODO reported|12 // TODO after the block|"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("directives did not suppress the expected comments")
+	}
+
+	// # is not a comment in Go, so the last directive is not found at all
+	if len(unused) != 0 {
+		t.Fatalf("unexpected unused directives %v", unused)
+	}
+}
+
+func TestUnusedSuppressions(t *testing.T) {
+	src := "#!/bin/sh\n# commentlex:ignore-next-line\necho hi\n# TODO real\n# commentlex:disable\n"
+	comments := lexer.ReadComments("a.sh", strings.NewReader(src), "")
+	kept, unused := lexer.Suppress(comments, nil)
+
+	if len(kept) != 2 || kept[1].Text != "# TODO real" {
+		t.Fatalf("unexpected comments kept %v", kept)
+	}
+	if len(unused) != 2 || unused[0].Pos.Line != 2 || unused[1].Pos.Line != 5 {
+		t.Fatalf("unexpected unused directives %v", unused)
+	}
+}
@@ -0,0 +1,70 @@
+package lexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultTags lists the tags commonly used to mark comments that need work.
+var DefaultTags = []string{"TODO", "FIXME", "HACK", "XXX", "BUG", "NOTE"}
+
+// HasTag reports whether the comment carries tag. A tag is a whole word that
+// is either written exactly as given, as in "TODO: fix", or prefixed with an
+// @ in any case, as in "@todo fix".
+func (c CommentInfo) HasTag(tag string) bool {
+	return tagIndex(c.Text, tag) >= 0
+}
+
+// Tags returns the tags in tags that the comment carries, in the order of
+// tags. DefaultTags is used when tags is nil.
+func (c CommentInfo) Tags(tags []string) []string {
+	if tags == nil {
+		tags = DefaultTags
+	}
+	var found []string
+	for _, tag := range tags {
+		if c.HasTag(tag) {
+			found = append(found, tag)
+		}
+	}
+	return found
+}
+
+// tagIndex returns the index of the first occurrence of tag in text, see
+// HasTag, or -1. The index of a tag prefixed with @ is the index of the @.
+func tagIndex(text string, tag string) int {
+	if tag == "" {
+		return -1
+	}
+	// offsets of a lower cased text may differ, so compare in place
+	for start := 0; start+len(tag) <= len(text); start++ {
+		end := start + len(tag)
+		if !utf8.RuneStart(text[start]) || !strings.EqualFold(text[start:end], tag) {
+			continue
+		}
+		if end < len(text) && isWordByte(text, end) {
+			continue
+		}
+		if start > 0 && text[start-1] == '@' {
+			if start == 1 || !isWordByte(text, start-2) {
+				return start - 1
+			}
+			continue
+		}
+		if text[start:end] == tag && (start == 0 || !isWordByte(text, start-1)) {
+			return start
+		}
+	}
+	return -1
+}
+
+// isWordByte reports whether the character at text[i] is a letter, digit or
+// underscore. For multi byte characters i may point at any byte of it.
+func isWordByte(text string, i int) bool {
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
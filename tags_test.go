@@ -0,0 +1,35 @@
+package lexer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestCommentTags(t *testing.T) {
+	src := "// TODO: plain tag\n//@todo lower case tag\n// todo is only a word here\n// FIXME(bob) and HACK\n// TODOS or email@todo are not tags\n"
+	res := ""
+	for _, c := range lexer.ReadComments("a.go", strings.NewReader(src), "") {
+		res += strings.Join(c.Tags(nil), ",") + "|"
+	}
+
+	want := "TODO|TODO||FIXME,HACK||"
+	if res != want {
+		fmt.Println("got", res, "want", want)
+		t.Fatalf("tags not detected as expected")
+	}
+}
+
+func TestCommentTagsNonASCII(t *testing.T) {
+	// Ⱥ is longer in bytes when lower cased
+	c := lexer.CommentInfo{Text: "// ȺȺȺȺȺȺȺȺȺȺ TODO: é @Fixme"}
+	if got := strings.Join(c.Tags(nil), ","); got != "TODO,FIXME" {
+		t.Fatalf("got %q want %q", got, "TODO,FIXME")
+	}
+	c = lexer.CommentInfo{Text: "// ȺTODO"}
+	if c.HasTag("TODO") {
+		t.Fatalf("tag inside a word found in %q", c.Text)
+	}
+}
@@ -0,0 +1,195 @@
+package lexer
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Updated is the kind of the changes sent by a Watcher for comments that
+// changed or moved.
+const Updated = "updated"
+
+// A Watcher watches a directory tree and reports how its comments change as
+// files are written, created or removed. Only changed files are scanned
+// again.
+type Watcher struct {
+	Match    string        // only report comments matching Match, see Scanner.Match
+	Debounce time.Duration // time without changes before files are scanned, 100ms if 0
+	Ignore   *Ignore       // paths to skip, relative to the watched directory
+}
+
+// notifier reports changes of files in directories that are added to it.
+// Changed paths are sent on the channel returned by events.
+type notifier interface {
+	add(dir string) error
+	events() <-chan string
+	close() error
+}
+
+// watchState is the state of a running Watch.
+type watchState struct {
+	*Watcher
+	n       notifier
+	root    string
+	dirs    map[string]bool          // watched directories
+	files   map[string][]CommentInfo // comments per scanned file
+	changes []CommentChange          // changes found since the last call of fn
+}
+
+// Watch scans the tree at root and then calls fn with the changes of its
+// comments until ctx is done or fn returns an error. The first call reports
+// every comment as added. Changes are Added, Removed or Updated. File names
+// of the comments are relative to root.
+func (w *Watcher) Watch(ctx context.Context, root string, fn func([]CommentChange) error) error {
+	n, err := newNotifier()
+	if err != nil {
+		return err
+	}
+	defer n.close()
+
+	s := &watchState{
+		Watcher: w,
+		n:       n,
+		root:    root,
+		dirs:    make(map[string]bool),
+		files:   make(map[string][]CommentInfo),
+	}
+	if err := s.addTree("."); err != nil {
+		return err
+	}
+	if err := fn(s.changes); err != nil {
+		return err
+	}
+
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = 100 * time.Millisecond
+	}
+	pending := make(map[string]bool)
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case path, ok := <-n.events():
+			if !ok {
+				return nil
+			}
+			if rel, err := filepath.Rel(root, path); err == nil {
+				pending[rel] = true
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for p := range pending {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			pending = make(map[string]bool)
+
+			s.changes = nil
+			for _, p := range paths {
+				if err := s.rescan(p); err != nil {
+					return err
+				}
+			}
+			if len(s.changes) > 0 {
+				if err := fn(s.changes); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// addTree watches the directory rel and everything below it and scans its
+// files.
+func (s *watchState) addTree(rel string) error {
+	return filepath.WalkDir(filepath.Join(s.root, rel), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// removed while walking
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && s.Ignore.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return s.rescan(rel)
+		}
+		if s.dirs[rel] {
+			return nil
+		}
+		s.dirs[rel] = true
+		return s.n.add(path)
+	})
+}
+
+// rescan scans the file rel again, or watches it if it is a new directory,
+// and records the changes of its comments.
+func (s *watchState) rescan(rel string) error {
+	info, err := os.Stat(filepath.Join(s.root, rel))
+	if err == nil && info.IsDir() {
+		if s.dirs[rel] || s.Ignore.Ignored(rel, true) {
+			return nil
+		}
+		return s.addTree(rel)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var found []CommentInfo
+	if err == nil && Supported(rel) && !s.Ignore.Ignored(rel, false) {
+		if found, err = ScanFile(filepath.Join(s.root, rel), s.Match); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for i := range found {
+			found[i].Pos.Filename, found[i].End.Filename = rel, rel
+		}
+	}
+
+	old := s.files[rel]
+	if os.IsNotExist(err) && s.dirs[rel] {
+		// a removed directory takes the files below it along
+		prefix := rel + string(filepath.Separator)
+		for file, comments := range s.files {
+			if strings.HasPrefix(file, prefix) {
+				old = append(old, comments...)
+				delete(s.files, file)
+			}
+		}
+		for dir := range s.dirs {
+			if dir == rel || strings.HasPrefix(dir, prefix) {
+				delete(s.dirs, dir)
+			}
+		}
+	}
+	if found == nil {
+		delete(s.files, rel)
+	} else {
+		s.files[rel] = found
+	}
+	for _, c := range CompareComments(old, found) {
+		if c.Kind == Changed || c.Kind == Moved {
+			c.Kind = Updated
+		}
+		s.changes = append(s.changes, c)
+	}
+	return nil
+}
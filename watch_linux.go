@@ -0,0 +1,103 @@
+package lexer
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask selects the inotify events that change the comments of a tree.
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF
+
+// inotify is a notifier using the Linux inotify API.
+type inotify struct {
+	file *os.File
+	ch   chan string
+	done chan struct{}
+
+	mu   sync.Mutex
+	dirs map[int32]string // watched directory per watch descriptor
+}
+
+func newNotifier() (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	n := &inotify{
+		// a non blocking file uses the runtime poller, so close stops read
+		file: os.NewFile(uintptr(fd), "inotify"),
+		ch:   make(chan string, 64),
+		done: make(chan struct{}),
+		dirs: make(map[int32]string),
+	}
+	go n.read()
+	return n, nil
+}
+
+func (n *inotify) add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(int(n.file.Fd()), dir, inotifyMask)
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+	n.mu.Lock()
+	n.dirs[int32(wd)] = dir
+	n.mu.Unlock()
+	return nil
+}
+
+func (n *inotify) events() <-chan string { return n.ch }
+
+func (n *inotify) close() error {
+	close(n.done)
+	return n.file.Close()
+}
+
+// read sends the path of each event until the inotify file is closed.
+func (n *inotify) read() {
+	defer close(n.ch)
+	var buf [64 * (syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1)]byte
+	for {
+		size, err := n.file.Read(buf[:])
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= size; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+
+			n.mu.Lock()
+			dir, ok := n.dirs[ev.Wd]
+			if ev.Mask&syscall.IN_IGNORED != 0 {
+				delete(n.dirs, ev.Wd)
+			}
+			n.mu.Unlock()
+			if !ok {
+				continue
+			}
+			path := dir
+			if i := indexNul(name); i > 0 {
+				path = filepath.Join(dir, string(name[:i]))
+			}
+			select {
+			case n.ch <- path:
+			case <-n.done:
+				return
+			}
+		}
+	}
+}
+
+// indexNul returns the length of the NUL padded name of an inotify event.
+func indexNul(name []byte) int {
+	for i, b := range name {
+		if b == 0 {
+			return i
+		}
+	}
+	return len(name)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package lexer
+
+import "errors"
+
+func newNotifier() (notifier, error) {
+	return nil, errors.New("watching is only supported on linux")
+}
@@ -0,0 +1,76 @@
+package lexer_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	lexer "github.com/Acetolyne/commentlex"
+)
+
+func TestWatchReportsChanges(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("watching is only supported on linux")
+	}
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "// TODO one\n")
+	write("skip/b.go", "// TODO ignored\n")
+	ig, err := lexer.NewIgnore("skip/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	batches := make(chan string, 10)
+	w := &lexer.Watcher{Debounce: 20 * time.Millisecond, Ignore: ig}
+	go w.Watch(ctx, root, func(changes []lexer.CommentChange) error {
+		res := ""
+		for _, c := range changes {
+			cm := c.New
+			if cm == nil {
+				cm = c.Old
+			}
+			res += fmt.Sprintf("%s %s:%d %s|", c.Kind, cm.Pos.Filename, cm.Pos.Line, cm.Text)
+		}
+		batches <- res
+		return nil
+	})
+	next := func() string {
+		select {
+		case b := <-batches:
+			return b
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for changes")
+		}
+		return ""
+	}
+
+	if got, want := next(), "added a.go:1 // TODO one|"; got != want {
+		t.Fatalf("initial scan: got %q want %q", got, want)
+	}
+	write("a.go", "\n// TODO one\n// TODO two\n")
+	if got, want := next(), "updated a.go:2 // TODO one|added a.go:3 // TODO two|"; got != want {
+		t.Fatalf("after write: got %q want %q", got, want)
+	}
+	write("pkg/c.go", "// TODO new dir\n")
+	write("skip/b.go", "// TODO still ignored\n")
+	if got, want := next(), fmt.Sprintf("added %s:1 // TODO new dir|", filepath.Join("pkg", "c.go")); got != want {
+		t.Fatalf("after new directory: got %q want %q", got, want)
+	}
+	os.Remove(filepath.Join(root, "a.go"))
+	if got, want := next(), "removed a.go:2 // TODO one|removed a.go:3 // TODO two|"; got != want {
+		t.Fatalf("after remove: got %q want %q", got, want)
+	}
+}